@@ -1,6 +1,8 @@
 package webos
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +22,12 @@ const (
 	// RequestMessageType is sent to the TV when issuing Commands.
 	RequestMessageType MessageType = "request"
 
+	// SubscribeMessageType is sent to the TV to subscribe to changes returned by a Command.
+	SubscribeMessageType MessageType = "subscribe"
+
+	// UnsubscribeMessageType is sent to the TV to cancel a subscription.
+	UnsubscribeMessageType MessageType = "unsubscribe"
+
 	// ResponseMessageType is returned by the TV in response to a request.
 	ResponseMessageType MessageType = "response"
 )
@@ -36,21 +44,28 @@ type Message struct {
 
 // Validate validates the Message.
 //  Only used for response (type: response || registered) types.
+// Errors returned by the TV are returned as an *APIError.
 func (m Message) Validate() error {
 	switch m.Type {
 	case ErrorMessageType:
-		var err error
-		if _, ok := m.Payload["returnValue"]; ok {
-			err = m.Payload.Validate()
-		}
-
-		if err == nil {
-			return errors.New(m.Error)
+		apiErr := parseAPIError(m.Error)
+		if m.Payload != nil && m.Payload["errorCode"] != nil {
+			if apiErr.Code == 0 {
+				apiErr.Code = errorCode(m.Payload["errorCode"])
+			}
+			if text := errorText(m.Payload["errorText"]); text != "" && text != apiErr.Text {
+				apiErr.Text = strings.TrimPrefix(apiErr.Text+", "+text, ", ")
+			}
 		}
 
-		return errors.Errorf("API error: %s, %s", m.Error, err)
+		apiErr.ID = m.ID
+		return apiErr
 	case ResponseMessageType:
-		return m.Payload.Validate()
+		err := m.Payload.Validate()
+		if apiErr, ok := err.(*APIError); ok {
+			apiErr.ID = m.ID
+		}
+		return err
 	case RegisteredMessageType:
 		if m.Payload == nil {
 			return errors.New("empty payload")
@@ -64,7 +79,7 @@ func (m Message) Validate() error {
 // Payload represents the Payload contained in the Message body.
 type Payload map[string]interface{}
 
-// Validate valides the Payload.
+// Validate valides the Payload. A false `returnValue` is returned as an *APIError.
 func (p Payload) Validate() error {
 	if p == nil {
 		return errors.New("empty payload")
@@ -82,10 +97,10 @@ func (p Payload) Validate() error {
 
 	if !returnValue {
 		if p["errorCode"] != nil {
-			return errors.Errorf("error %v: %v", p["errorCode"], p["errorText"])
+			return &APIError{Code: errorCode(p["errorCode"]), Text: errorText(p["errorText"])}
 		}
 
-		return errors.New("`returnValue` is false and `errorCode` is nil")
+		return &APIError{Text: "`returnValue` is false and `errorCode` is nil"}
 	}
 
 	return nil
@@ -93,29 +108,79 @@ func (p Payload) Validate() error {
 
 // App represents an applications in the TVs responses.
 type App struct {
-	ReturnValue bool
-	AppID       string
-	WindowID    string
-	ProcessID   string
-	Running     bool
-	Visible     bool
+	ReturnValue bool   `json:"returnValue"`
+	AppID       string `json:"appId"`
+	WindowID    string `json:"windowId"`
+	ProcessID   string `json:"processId"`
+	Running     bool   `json:"running"`
+	Visible     bool   `json:"visible"`
+}
+
+// InstalledApp represents an installed application in the TVs responses.
+type InstalledApp struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version string `json:"version"`
+	Visible bool   `json:"visible"`
+}
+
+// AppList represents an array of InstalledApp types in the TVs responses.
+type AppList struct {
+	Apps []InstalledApp `json:"apps"`
+}
+
+// ExternalInput represents an external input, e.g. a HDMI port, in the TVs responses.
+type ExternalInput struct {
+	ID        string `json:"id"`
+	Label     string `json:"label"`
+	Connected bool   `json:"connected"`
+	AppID     string `json:"appId"`
+}
+
+// ExternalInputList represents an array of ExternalInput types in the TVs responses.
+type ExternalInputList struct {
+	Devices []ExternalInput `json:"devices"`
 }
 
 // Service represents services in the TVs responses.
 type Service struct {
-	Name    string
-	Version float32
+	Name    string  `json:"name"`
+	Version float32 `json:"version"`
 }
 
 // ServiceList represents an array of Service types in the TVs responses.
 type ServiceList struct {
-	Services []Service
+	Services []Service `json:"services"`
+}
+
+// SystemInfo represents the TV model and hardware features in the TVs responses.
+type SystemInfo struct {
+	ModelName    string          `json:"modelName"`
+	ReceiverType string          `json:"receiverType"`
+	Features     map[string]bool `json:"features"`
+}
+
+// SoftwareInfo represents the TV software and firmware versions in the TVs responses.
+type SoftwareInfo struct {
+	ProductName  string `json:"productName"`
+	ModelName    string `json:"modelName"`
+	MajorVersion string `json:"majorVersion"`
+	MinorVersion string `json:"minorVersion"`
+	Country      string `json:"country"`
+}
+
+// PowerState represents the TV power state, e.g. "Active" or "Screen Off", in the TVs responses.
+type PowerState struct {
+	State      string `json:"state"`
+	Processing string `json:"processing"`
 }
 
 // Volume represents the audio output volume in the TVs responses.
 type Volume struct {
-	ReturnValue bool
-	Scenario    string
-	Volume      int32
-	Muted       bool
+	ReturnValue bool   `json:"returnValue"`
+	Scenario    string `json:"scenario"`
+	Volume      int32  `json:"volume"`
+	MaxVolume   int32  `json:"maxVolume"`
+	Muted       bool   `json:"muted"`
+	SoundOutput string `json:"soundOutput"`
 }
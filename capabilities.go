@@ -0,0 +1,238 @@
+package webos
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupported is returned by command methods when the TV's Capabilities show
+// the command isn't supported. The returned error is an *UnsupportedError which
+// wraps ErrUnsupported.
+var ErrUnsupported = errors.New("command not supported by the TV")
+
+// UnsupportedError describes a Command which isn't supported by the TV.
+type UnsupportedError struct {
+	URI    Command
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrUnsupported, e.URI, e.Reason)
+}
+
+// Unwrap returns ErrUnsupported.
+func (e *UnsupportedError) Unwrap() error {
+	return ErrUnsupported
+}
+
+// enumeratedServices are the services the TV lists in the getServiceList response. Commands
+// for other services (e.g. `com.webos.*`) can't be checked against the service list.
+var enumeratedServices = map[string]bool{
+	"api":                  true,
+	"audio":                true,
+	"config":               true,
+	"media.controls":       true,
+	"media.viewer":         true,
+	"pairing":              true,
+	"settings":             true,
+	"system":               true,
+	"system.launcher":      true,
+	"system.notifications": true,
+	"timer":                true,
+	"tv":                   true,
+	"user":                 true,
+	"webapp":               true,
+}
+
+// probedServices are services the TV doesn't list in the getServiceList response, e.g.
+// the pointer input socket and screen off services, and the Commands used to detect
+// whether they are available. TVs without the service respond with a 404 error.
+var probedServices = map[string]Command{
+	"com.webos.service.networkinput": GetPointerInputSocketCommand,
+	"com.webos.service.tvpower":      PowerStateCommand,
+}
+
+// capabilitiesTimeout is how long detecting the Capabilities may take. It's shared by
+// the requests, so authorising isn't held up by a TV which doesn't respond to them.
+const capabilitiesTimeout = 5 * time.Second
+
+// Capabilities describe the services and features available on the TV. They are
+// detected by DetectCapabilities using the service list and system information, and
+// by probing the services the TV doesn't list.
+type Capabilities struct {
+	Services   map[string]float32
+	SystemInfo SystemInfo
+	Software   SoftwareInfo
+
+	// probed are the probedServices whose availability is known.
+	probed map[string]bool
+}
+
+// WebOSVersion returns the webOS major version of the TV, or 0 if it is unknown.
+func (c *Capabilities) WebOSVersion() int {
+	if c == nil {
+		return 0
+	}
+
+	// product_name is reported as e.g. "webOSTV 3.0" or "webOS TV 4.5".
+	name := strings.TrimSpace(strings.TrimPrefix(strings.Replace(c.Software.ProductName, " ", "", 1), "webOSTV"))
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+
+	v, err := strconv.Atoi(name)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// Supports returns true if the Command is supported by the TV.
+func (c *Capabilities) Supports(uri Command) bool {
+	return c.check(uri) == nil
+}
+
+// check returns an *UnsupportedError if the Command isn't supported by the TV. All Commands
+// are considered supported when the Capabilities are unknown.
+func (c *Capabilities) check(uri Command) error {
+	if c == nil {
+		return nil
+	}
+
+	service := uri.Service()
+	if _, ok := c.Services[service]; !ok && (enumeratedServices[service] || c.probed[service]) {
+		return &UnsupportedError{URI: uri, Reason: fmt.Sprintf("service %q is not available", service)}
+	}
+
+	if feature, ok := commandFeatures[uri]; ok {
+		if enabled, known := c.SystemInfo.Features[feature]; known && !enabled {
+			return &UnsupportedError{URI: uri, Reason: fmt.Sprintf("feature %q is not available", feature)}
+		}
+	}
+
+	if min, ok := minimumVersions[uri]; ok {
+		if v := c.WebOSVersion(); v != 0 && v < min {
+			return &UnsupportedError{URI: uri, Reason: fmt.Sprintf("requires webOS %d, TV has webOS %d", min, v)}
+		}
+	}
+
+	return nil
+}
+
+// Service returns the service part of the Command, e.g. `system.launcher` for
+// `ssap://system.launcher/launch`.
+func (c Command) Service() string {
+	s := strings.TrimPrefix(string(c), "ssap://")
+	if i := strings.Index(s, "/"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// Capabilities returns the Capabilities detected by DetectCapabilities, or nil if
+// they haven't been detected.
+func (tv *TV) Capabilities() *Capabilities {
+	if tv == nil {
+		return nil
+	}
+	return tv.caps.Load()
+}
+
+// DetectCapabilities inspects the service list and system information of the TV, and
+// probes the services it doesn't list, once authorised. The requests are made
+// concurrently, and share a deadline of 5 seconds. Once detected, Commands which
+// the Capabilities show to be unsupported fail without making a request.
+//
+// Detecting the Capabilities is opt-in, as it makes several requests, including one
+// for the pointer input socket. All Commands are considered supported until they are
+// detected.
+func (tv *TV) DetectCapabilities(ctx context.Context) (*Capabilities, error) {
+	ctx, cancel := context.WithTimeout(ctx, capabilitiesTimeout)
+	defer cancel()
+
+	var (
+		wg                  sync.WaitGroup
+		sl                  ServiceList
+		si                  SystemInfo
+		sw                  SoftwareInfo
+		slErr, siErr, swErr error
+	)
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+
+	run(func() { sl, slErr = ServiceListEndpoint.Call(ctx, tv, Empty{}) })
+	run(func() { si, siErr = SystemInfoEndpoint.Call(ctx, tv, Empty{}) })
+	run(func() { sw, swErr = SoftwareInfoEndpoint.Call(ctx, tv, Empty{}) })
+
+	var mu sync.Mutex
+	probed := make(map[string]error, len(probedServices))
+	for service, uri := range probedServices {
+		run(func() {
+			_, err := tv.CommandContext(ctx, uri, nil)
+			mu.Lock()
+			probed[service] = err
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	if slErr != nil {
+		return nil, errors.Wrap(slErr, "could not get service list")
+	}
+
+	// every TV has services, an empty list (e.g. from DryRunMiddleware) would make
+	// every command unsupported
+	if len(sl.Services) == 0 {
+		return nil, errors.New("empty service list")
+	}
+
+	if siErr != nil {
+		return nil, errors.Wrap(siErr, "could not get system info")
+	}
+
+	// the software information requires READ_UPDATE_INFO, which reduced
+	// ManifestPresets don't request, so the webOS version is unknown without it
+	if swErr != nil && !stderrors.Is(swErr, ErrPermissionDenied) {
+		return nil, errors.Wrap(swErr, "could not get software info")
+	}
+
+	caps := &Capabilities{
+		Services:   make(map[string]float32, len(sl.Services)+len(probed)),
+		SystemInfo: si,
+		Software:   sw,
+		probed:     make(map[string]bool, len(probed)),
+	}
+	for _, s := range sl.Services {
+		caps.Services[s.Name] = s.Version
+	}
+
+	// a probe which failed for another reason, e.g. as its permission wasn't granted,
+	// leaves the service's availability unknown
+	for service, err := range probed {
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			caps.Services[service] = 1
+			caps.probed[service] = true
+		case stderrors.As(err, &apiErr) && apiErr.Code == 404:
+			caps.probed[service] = true
+		}
+	}
+
+	tv.caps.Store(caps)
+	return caps, nil
+}
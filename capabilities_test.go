@@ -0,0 +1,133 @@
+package webos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// connect dials the Server and registers using its client key.
+func connect(t testing.TB, srv *webostest.Server, opts ...webos.Option) *webos.TV {
+	t.Helper()
+
+	tv, err := webos.Dial(srv.Host(), append([]webos.Option{webos.WithDialer(srv.Dialer())}, opts...)...)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	t.Cleanup(func() { tv.Close() })
+	go tv.MessageHandler()
+
+	if err := tv.AuthoriseClientKey(webostest.DefaultClientKey); err != nil {
+		t.Fatalf("could not authorise: %v", err)
+	}
+	return tv
+}
+
+// detect connects to the Server and detects the TV's Capabilities.
+func detect(t testing.TB, srv *webostest.Server) *webos.TV {
+	t.Helper()
+
+	tv := connect(t, srv)
+	if _, err := tv.DetectCapabilities(context.Background()); err != nil {
+		t.Fatalf("DetectCapabilities: %v", err)
+	}
+	return tv
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.SoftwareInfoCommand, webos.Payload{"product_name": "webOSTV 5.0", "model_name": "HE_DTV_W20H_AFADABAA", "major_ver": "03", "minor_ver": "21.30"})
+	srv.RespondStubs()
+
+	caps := detect(t, srv).Capabilities()
+	if caps == nil {
+		t.Fatal("no Capabilities detected")
+	}
+	if v := caps.WebOSVersion(); v != 5 {
+		t.Errorf("WebOSVersion is %d, want 5", v)
+	}
+
+	supported := map[webos.Command]bool{
+		webos.AudioGetVolumeCommand:        true,
+		webos.GetPointerInputSocketCommand: true,
+		webos.ScreenOffCommand:             true,
+
+		// the service list has no webapp service
+		webos.Command("ssap://webapp/launchWebApp"): false,
+
+		// the 3d feature is false
+		webos.TVDisplaySet3DOnCommand: false,
+	}
+	for uri, want := range supported {
+		if got := caps.Supports(uri); got != want {
+			t.Errorf("Supports(%s) is %t, want %t", uri, got, want)
+		}
+	}
+}
+
+func TestDetectCapabilitiesProbes(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondError(webos.GetPointerInputSocketCommand, 404, "no such service or method")
+	srv.RespondError(webos.PowerStateCommand, 404, "no such service or method")
+	srv.RespondStubs()
+
+	tv := detect(t, srv)
+	for _, uri := range []webos.Command{webos.GetPointerInputSocketCommand, webos.ScreenOnCommand} {
+		if tv.Capabilities().Supports(uri) {
+			t.Errorf("%s is supported without its service", uri)
+		}
+	}
+
+	if _, err := tv.Input(); !errors.Is(err, webos.ErrUnsupported) {
+		t.Errorf("Input returned %v, want ErrUnsupported", err)
+	}
+}
+
+func TestDetectCapabilitiesUnknownProbe(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondError(webos.PowerStateCommand, 401, "insufficient permissions")
+	srv.RespondStubs()
+
+	// the service's availability is unknown, so its Commands aren't checked
+	if caps := detect(t, srv).Capabilities(); !caps.Supports(webos.PowerStateCommand) {
+		t.Error("PowerStateCommand isn't supported after its probe was denied")
+	}
+}
+
+func TestCapabilitiesOptIn(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	// registering doesn't make requests for the Capabilities
+	tv := connect(t, srv)
+	if caps := tv.Capabilities(); caps != nil {
+		t.Errorf("Capabilities were detected when registering: %+v", caps)
+	}
+	for _, msg := range srv.Messages() {
+		if msg.Type != webos.RegisterMessageType {
+			t.Errorf("registering made a %s request to %s", msg.Type, msg.URI)
+		}
+	}
+}
+
+func TestDetectCapabilitiesError(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondError(webos.APIServiceListCommand, 500, "internal error")
+	srv.RespondStubs()
+
+	tv := connect(t, srv)
+	if _, err := tv.DetectCapabilities(context.Background()); err == nil {
+		t.Error("DetectCapabilities succeeded without a service list")
+	}
+	if tv.Capabilities() != nil {
+		t.Error("Capabilities were stored despite the error")
+	}
+}
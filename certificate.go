@@ -0,0 +1,87 @@
+package webos
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint returns the fingerprint used to pin a certificate, the hex encoded
+// SHA-256 hash of the certificate.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// WithCertificatePin verifies the TV's certificate has the fingerprint, returning
+// ErrCertificateChanged if it doesn't. It replaces verification against the
+// certificate authorities, as the TV's certificate is self-signed, and stops Dial
+// falling back to ws.
+//
+// The fingerprint is recorded on first use, e.g. when pairing, using
+// CertificateFingerprint:
+//
+//	tv, err := webos.Dial(address)
+//	...
+//	key, err := tv.AuthorisePrompt()
+//	...
+//	save(key, tv.CertificateFingerprint())
+//
+// and pinned on subsequent connections:
+//
+//	tv, err := webos.Dial(address, webos.WithCertificatePin(fingerprint))
+func WithCertificatePin(fingerprint string) Option {
+	return func(o *options) {
+		o.pin = fingerprint
+	}
+}
+
+// CertificateFingerprint returns the Fingerprint of the TV's certificate, or an empty
+// string if the TV wasn't connected to by Dial using wss. The pointer input socket is
+// verified to use the same certificate.
+func (tv *TV) CertificateFingerprint() string {
+	return tv.fingerprint
+}
+
+// pinCertificate returns a copy of the TLS configuration which verifies the
+// certificate has the fingerprint, if given, and stores the fingerprint of the
+// certificate in seen, if not nil.
+func pinCertificate(c *tls.Config, fingerprint string, seen *string) *tls.Config {
+	if c == nil {
+		c = &tls.Config{}
+	} else {
+		c = c.Clone()
+	}
+
+	if fingerprint != "" {
+		c.InsecureSkipVerify = true
+	}
+
+	verify := c.VerifyConnection
+	c.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(cs); err != nil {
+				return err
+			}
+		}
+
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no certificate")
+		}
+
+		fp := Fingerprint(cs.PeerCertificates[0])
+		if fingerprint != "" && !strings.EqualFold(fp, fingerprint) {
+			return fmt.Errorf("%w: got %s, pinned %s", ErrCertificateChanged, fp, fingerprint)
+		}
+
+		if seen != nil {
+			*seen = fp
+		}
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,93 @@
+package webos_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// fingerprint dials the Server and returns the fingerprint of its certificate.
+func fingerprint(t *testing.T, srv *webostest.Server) string {
+	t.Helper()
+
+	fp := dial(t, srv).CertificateFingerprint()
+	if fp == "" {
+		t.Fatal("no certificate fingerprint was recorded")
+	}
+	return fp
+}
+
+// impersonator returns a TLS server with a different certificate to the Server's,
+// e.g. another device impersonating the TV.
+func impersonator(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCertificatePin(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	fp := fingerprint(t, srv)
+	if tv := connect(t, srv, webos.WithCertificatePin(strings.ToUpper(fp))); tv.CertificateFingerprint() != fp {
+		t.Errorf("pinned TV has the fingerprint %s, want %s", tv.CertificateFingerprint(), fp)
+	}
+
+	wrong := strings.Repeat("0", len(fp))
+	_, err := webos.Dial(srv.Host(), webos.WithDialer(srv.Dialer()), webos.WithCertificatePin(wrong))
+	if !errors.Is(err, webos.ErrCertificateChanged) {
+		t.Errorf("Dial returned %v with the wrong pin, want ErrCertificateChanged", err)
+	}
+}
+
+func TestCertificatePinInput(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv := connect(t, srv, webos.WithCertificatePin(fingerprint(t, srv)))
+	if err := tv.KeyHome(); err != nil {
+		t.Fatalf("KeyHome: %v", err)
+	}
+	eventually(t, func() bool { return reflect.DeepEqual(srv.Buttons(), []string{"HOME"}) })
+
+	// the pointer input socket is served with another certificate
+	other := impersonator(t)
+	srv.Respond(webos.GetPointerInputSocketCommand, webos.Payload{"socketPath": "wss://" + other.Listener.Addr().String() + "/pointer"})
+
+	tv = connect(t, srv, webos.WithCertificatePin(fingerprint(t, srv)))
+	if _, err := tv.Input(); !errors.Is(err, webos.ErrCertificateChanged) {
+		t.Errorf("Input returned %v for a socket with another certificate, want ErrCertificateChanged", err)
+	}
+}
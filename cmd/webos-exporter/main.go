@@ -0,0 +1,91 @@
+// Command webos-exporter exports metrics about one or more webOS TVs for Prometheus.
+//
+//	webos-exporter -listen :9715 -tv living-room=192.168.1.67,6c7b2ec679ffd1c2736abd621153eabb
+//	webos-exporter -listen :9715 -config tvs.yaml
+//
+// Request counts, latencies and errors by URI are exported along with the TV state:
+// volume, mute, power state and the foreground app. Metrics are served at GET /metrics.
+// The exporter reconnects when the connection to a TV is lost, e.g. when it's turned off.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/config"
+)
+
+func main() {
+	listen := flag.String("listen", ":9715", "address to serve metrics on")
+	retry := flag.Duration("retry", 30*time.Second, "time to wait before reconnecting to a TV")
+	configPath := flag.String("config", "", "configuration file of the TVs to export, see the config package")
+	tvs := config.DeviceFlags{}
+	flag.Var(tvs, "tv", "TV to export in the format name=address,client-key, may be repeated")
+	flag.Parse()
+
+	cfg, err := config.LoadWithFlags(*configPath, tvs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(cfg.Devices) == 0 {
+		flag.Usage()
+		log.Fatal("at least one -tv or a -config is required")
+	}
+
+	// the request metrics are labelled with the name, and report each connection
+	metrics := make(map[string]*requestMetrics, len(cfg.Devices))
+	for _, name := range cfg.Names() {
+		metrics[name] = &requestMetrics{tv: name}
+	}
+
+	m := cfg.Manager()
+	m.Setup = func(name string, tv *webos.TV) {
+		tv.SetMetrics(metrics[name])
+	}
+	defer m.Close()
+
+	for _, name := range cfg.Names() {
+		go export(m, name, *retry)
+	}
+
+	http.Handle("/metrics", promhttp.Handler())
+
+	log.Printf("serving on %s", *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
+
+// export watches the TV and exports its state until the connection is lost, then
+// reconnects after the retry interval.
+func export(m *webos.Manager, name string, retry time.Duration) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retry)
+		}
+
+		tv, err := m.TV(name)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		// the Manager owns the TV, and reconnects to it once the connection is lost
+		w, err := tv.Watch(webos.VolumeEvent, webos.AppEvent, webos.PowerEvent)
+		if err != nil {
+			log.Printf("could not watch %s: %v", name, err)
+			continue
+		}
+
+		for e := range w.C {
+			setState(name, e)
+		}
+		resetState(name)
+
+		log.Printf("lost connection to %s", name)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webos_requests_total",
+		Help: "Requests made to the TV.",
+	}, []string{"tv", "uri"})
+
+	requestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webos_request_errors_total",
+		Help: "Requests which failed, including timeouts.",
+	}, []string{"tv", "uri"})
+
+	requestTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webos_request_timeouts_total",
+		Help: "Requests which timed out waiting for a response.",
+	}, []string{"tv", "uri"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "webos_request_duration_seconds",
+		Help:    "Time taken for the TV to respond to requests.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 15},
+	}, []string{"tv", "uri"})
+
+	inFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webos_requests_in_flight",
+		Help: "Requests waiting for a response.",
+	}, []string{"tv"})
+
+	reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webos_reconnects_total",
+		Help: "Reconnections after the connection to the TV was lost.",
+	}, []string{"tv"})
+
+	up = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webos_up",
+		Help: "Whether the TV is connected.",
+	}, []string{"tv"})
+
+	volume = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webos_volume",
+		Help: "Volume of the TV.",
+	}, []string{"tv"})
+
+	muted = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webos_muted",
+		Help: "Whether the TV is muted.",
+	}, []string{"tv"})
+
+	powerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webos_power_state",
+		Help: "Power state of the TV, e.g. Active or Screen Off, as a label. The current state is 1.",
+	}, []string{"tv", "state"})
+
+	foregroundApp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webos_foreground_app",
+		Help: "Foreground app of the TV as a label. The current app is 1.",
+	}, []string{"tv", "app"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		requests, requestErrors, requestTimeouts, requestDuration, inFlight, reconnects,
+		up, volume, muted, powerState, foregroundApp,
+	)
+}
+
+// requestMetrics implements webos.Metrics for a TV, across its connections.
+type requestMetrics struct {
+	tv          string
+	connections atomic.Int64
+}
+
+// RequestStarted implements webos.Metrics.
+func (m *requestMetrics) RequestStarted(uri webos.Command) {
+	inFlight.WithLabelValues(m.tv).Inc()
+}
+
+// RequestFinished implements webos.Metrics.
+func (m *requestMetrics) RequestFinished(uri webos.Command, d time.Duration, err error) {
+	inFlight.WithLabelValues(m.tv).Dec()
+
+	requests.WithLabelValues(m.tv, string(uri)).Inc()
+	requestDuration.WithLabelValues(m.tv, string(uri)).Observe(d.Seconds())
+
+	if err != nil {
+		requestErrors.WithLabelValues(m.tv, string(uri)).Inc()
+	}
+	if errors.Is(err, webos.ErrTimeout) {
+		requestTimeouts.WithLabelValues(m.tv, string(uri)).Inc()
+	}
+}
+
+// Connected implements webos.Metrics.
+func (m *requestMetrics) Connected() {
+	if m.connections.Add(1) > 1 {
+		reconnects.WithLabelValues(m.tv).Inc()
+	}
+	up.WithLabelValues(m.tv).Set(1)
+}
+
+// Disconnected implements webos.Metrics.
+func (m *requestMetrics) Disconnected(err error) {
+	up.WithLabelValues(m.tv).Set(0)
+}
+
+// setState sets the state gauges from the Event.
+func setState(tv string, e webos.Event) {
+	switch data := e.Data.(type) {
+	case *webos.Volume:
+		volume.WithLabelValues(tv).Set(float64(data.Volume))
+		muted.WithLabelValues(tv).Set(boolValue(data.Muted))
+	case *webos.App:
+		// only the current app is exported, the label would otherwise grow with every
+		// app used
+		foregroundApp.DeletePartialMatch(prometheus.Labels{"tv": tv})
+		foregroundApp.WithLabelValues(tv, data.AppID).Set(1)
+	case *webos.PowerState:
+		powerState.DeletePartialMatch(prometheus.Labels{"tv": tv})
+		powerState.WithLabelValues(tv, data.State).Set(1)
+	}
+}
+
+// resetState removes the state gauges of the TV while it is disconnected.
+func resetState(tv string) {
+	for _, g := range []*prometheus.GaugeVec{volume, muted, powerState, foregroundApp} {
+		g.DeletePartialMatch(prometheus.Labels{"tv": tv})
+	}
+}
+
+// boolValue returns 1 if b is true, otherwise 0.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
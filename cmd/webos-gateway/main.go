@@ -0,0 +1,75 @@
+// Command webos-gateway serves a JSON REST API for one or more webOS TVs.
+//
+//	webos-gateway -listen :8080 -tv living-room=192.168.1.67,6c7b2ec679ffd1c2736abd621153eabb
+//	webos-gateway -listen :8080 -config tvs.yaml
+//
+// With a single TV the API is served at the root, e.g. GET /volume. With more than one
+// TV each is served under /tvs/{name}/, e.g. GET /tvs/living-room/volume. The OpenAPI
+// document is served at GET /openapi.json. TVs which are off, or lose their
+// connection, are reconnected by the next request. Browsers may only open the events
+// websocket from the gateway's own host, unless other origins are given by -allow-origins.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kaperys/go-webos/config"
+	"github.com/kaperys/go-webos/httpapi"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to serve the API on")
+	configPath := flag.String("config", "", "configuration file of the TVs to serve, see the config package")
+	tvs := config.DeviceFlags{}
+	flag.Var(tvs, "tv", "TV to serve in the format name=address,client-key, may be repeated")
+	origins := flag.String("allow-origins", "", "comma separated origins allowed to open the events websocket, e.g. https://example.com, or * for any")
+	flag.Parse()
+
+	var opts []httpapi.Option
+	if *origins != "" {
+		opts = append(opts, httpapi.WithAllowedOrigins(strings.Split(*origins, ",")...))
+	}
+
+	if err := run(*listen, *configPath, tvs, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the API until the server fails. The TVs are connected when first used
+// and reconnected once the connection is lost, e.g. as the TV was turned off.
+func run(listen, configPath string, tvs config.DeviceFlags, opts []httpapi.Option) error {
+	cfg, err := config.LoadWithFlags(configPath, tvs)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Devices) == 0 {
+		flag.Usage()
+		return errors.New("at least one -tv or a -config is required")
+	}
+
+	m := cfg.Manager()
+	defer m.Close()
+
+	// connecting up front reports problems, e.g. an unknown client key, before
+	// serving, but TVs which are off are connected to by the first request
+	for _, name := range m.Names() {
+		if _, err := m.TV(name); err != nil {
+			log.Print(err)
+		}
+	}
+
+	var h http.Handler
+	if names := m.Names(); len(names) == 1 {
+		h = httpapi.NewManaged(m, names[0], opts...)
+	} else {
+		h = httpapi.NewManagerHandler(m, opts...)
+	}
+
+	log.Printf("serving on %s", listen)
+	return http.ListenAndServe(listen, h)
+}
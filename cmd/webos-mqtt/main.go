@@ -0,0 +1,95 @@
+// Command webos-mqtt bridges one or more webOS TVs to an MQTT broker for home automation.
+//
+//	webos-mqtt -broker tcp://localhost:1883 -tv living-room=192.168.1.67,6c7b2ec679ffd1c2736abd621153eabb
+//	webos-mqtt -broker tcp://localhost:1883 -config tvs.yaml
+//
+// Each TV's state is published to webos/<name>/state/... and commands are read from
+// webos/<name>/set/..., see the mqttbridge package. Home Assistant discovers the TVs
+// as devices. TVs are reconnected to once they're turned back on, and turned on with
+// Wake-on-LAN if the device has a MAC address.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/kaperys/go-webos/config"
+	"github.com/kaperys/go-webos/mqttbridge"
+)
+
+func main() {
+	broker := flag.String("broker", "tcp://localhost:1883", "address of the MQTT broker")
+	username := flag.String("username", "", "MQTT username")
+	password := flag.String("password", "", "MQTT password")
+	prefix := flag.String("prefix", "webos", "topic prefix")
+	discovery := flag.String("discovery-prefix", "homeassistant", "Home Assistant discovery prefix, discovery is disabled if empty")
+	configPath := flag.String("config", "", "configuration file of the TVs to bridge, see the config package")
+	tvs := config.DeviceFlags{}
+	flag.Var(tvs, "tv", "TV to bridge in the format name=address,client-key, may be repeated")
+	flag.Parse()
+
+	cfg, err := config.LoadWithFlags(*configPath, tvs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(cfg.Devices) == 0 {
+		flag.Usage()
+		log.Fatal("at least one -tv or a -config is required")
+	}
+
+	configs := make(map[string]mqttbridge.Config, len(cfg.Devices))
+	for _, name := range cfg.Names() {
+		configs[name] = mqttbridge.Config{
+			Name:             name,
+			Apps:             cfg.AppAliases(name),
+			Inputs:           cfg.InputAliases(name),
+			Prefix:           *prefix,
+			DiscoveryPrefix:  *discovery,
+			DisableDiscovery: *discovery == "",
+			QoS:              1,
+		}
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(*broker).
+		SetClientID("webos-mqtt").
+		SetUsername(*username).
+		SetPassword(*password)
+
+	// the will only covers one availability topic, with more than one TV each is
+	// shown as offline when its bridge is closed
+	if len(configs) == 1 {
+		for _, c := range configs {
+			opts.SetWill(c.AvailabilityTopic(), "offline", 1, true)
+		}
+	}
+
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		log.Fatalf("could not connect to %s: %v", *broker, token.Error())
+	}
+	defer client.Disconnect(250)
+
+	// TVs which are off are connected to once they're turned on
+	m := cfg.Manager()
+	defer m.Close()
+
+	for _, name := range cfg.Names() {
+		b := mqttbridge.NewManaged(m, client, configs[name])
+		if err := b.Start(); err != nil {
+			log.Fatalf("could not bridge %s: %v", name, err)
+		}
+		defer b.Close()
+
+		log.Printf("bridging %s", name)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	<-sig
+}
@@ -0,0 +1,284 @@
+// Command webos-remote is an interactive terminal remote control for webOS TVs.
+//
+//	webos-remote -name living-room
+//	webos-remote -tv living-room=192.168.1.67,6c7b2ec679ffd1c2736abd621153eabb
+//
+// TVs are read from the same configuration file as the webos command. A TV without
+// a client key is paired using the prompt shown on the TV, and its key is stored in
+// the configuration file.
+//
+// Arrow keys, Enter, Backspace and Home navigate, +/- change the volume and the
+// status panel shows the foreground app and volume as they change.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/config"
+)
+
+// help describes the key bindings.
+const help = "arrows: navigate  enter: ok  backspace: back  h: home  +/-: volume  m: mute  space: play/pause  q: quit"
+
+// remote is the terminal remote state, rendered as the status panel.
+type remote struct {
+	tv *webos.TV
+
+	mu     sync.Mutex
+	app    string
+	volume *webos.Volume
+	last   string
+	err    error
+	paused bool
+}
+
+func main() {
+	configPath := flag.String("config", config.DefaultPath(), "configuration file storing paired TVs")
+	name := flag.String("name", "", "TV to control, the default TV if empty")
+	tvs := config.DeviceFlags{}
+	flag.Var(tvs, "tv", "TV to control in the format name=address[,client-key], the TV prompts to pair without a client key")
+	flag.Parse()
+
+	cfg, err := config.LoadWithFlags(*configPath, tvs)
+	if errors.Is(err, os.ErrNotExist) {
+		cfg, err = config.LoadWithFlags("", tvs)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *name == "" && len(tvs) == 1 {
+		for n := range tvs {
+			*name = n
+		}
+	}
+
+	tv, err := connect(cfg, *configPath, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tv.Close()
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		log.Fatalf("could not put the terminal into raw mode: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	r := &remote{tv: tv}
+	r.watch()
+	r.render()
+	r.read()
+
+	// clear the status panel
+	fmt.Print("\x1b[2J\x1b[H")
+}
+
+// connect dials the named TV and authorises with its client key, pairing using the
+// prompt shown on the TV and storing the key if it has none.
+func connect(cfg *config.Config, path, name string) (*webos.TV, error) {
+	name, d, err := cfg.Device(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tv, err := cfg.DialDevice(d)
+	if errors.Is(err, webos.ErrCertificateChanged) {
+		return nil, fmt.Errorf("the TV's certificate has changed, pair it again if the TV was replaced: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if d.ClientKey == "" {
+		fmt.Println("accept the prompt shown on the TV")
+		key, err := tv.AuthorisePrompt()
+		if err != nil {
+			tv.Close()
+			return nil, fmt.Errorf("could not authorise using prompt: %w", err)
+		}
+
+		d.ClientKey, d.Fingerprint = key, tv.CertificateFingerprint()
+		if cfg.Default == "" {
+			cfg.Default = name
+		}
+		if err := cfg.Save(path); err != nil {
+			tv.Close()
+			return nil, fmt.Errorf("could not save configuration: %w", err)
+		}
+
+		fmt.Printf("paired with %s, saved to %s\n", name, path)
+		return tv, nil
+	}
+
+	if err := tv.AuthoriseClientKey(d.ClientKey); err != nil {
+		tv.Close()
+		return nil, fmt.Errorf("could not authorise, try pairing again: %w", err)
+	}
+
+	// TVs paired before certificates were pinned are pinned on first use
+	if d.Fingerprint == "" && tv.CertificateFingerprint() != "" {
+		d.Fingerprint = tv.CertificateFingerprint()
+		if err := cfg.Save(path); err != nil {
+			tv.Close()
+			return nil, fmt.Errorf("could not save configuration: %w", err)
+		}
+	}
+	return tv, nil
+}
+
+// watch subscribes to the foreground app and volume, updating the status panel
+// as they change.
+func (r *remote) watch() {
+	if sub, err := r.tv.Subscribe(webos.ApplicationManagerForegroundAppCommand, nil); err == nil {
+		go func() {
+			for msg := range sub.C {
+				a := &webos.App{}
+				if err := sub.Decode(msg, a); err != nil {
+					continue
+				}
+
+				r.mu.Lock()
+				r.app = a.AppID
+				r.mu.Unlock()
+				r.render()
+			}
+		}()
+	}
+
+	if sub, err := r.tv.Subscribe(webos.AudioGetVolumeCommand, nil); err == nil {
+		go func() {
+			for msg := range sub.C {
+				v := &webos.Volume{}
+				if err := sub.Decode(msg, v); err != nil {
+					continue
+				}
+
+				r.mu.Lock()
+				r.volume = v
+				r.mu.Unlock()
+				r.render()
+			}
+		}()
+	}
+}
+
+// read reads key presses from the terminal until q or Ctrl+C.
+func (r *remote) read() {
+	buf := make([]byte, 8)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+
+		in := string(buf[:n])
+		if in == "q" || in == "\x03" {
+			return
+		}
+
+		name, err := r.press(in)
+		if name == "" {
+			continue
+		}
+
+		r.mu.Lock()
+		r.last, r.err = name, err
+		r.mu.Unlock()
+		r.render()
+	}
+}
+
+// buttons map terminal input onto the names of the buttons pressed.
+var buttons = map[string]string{
+	"\x1b[A": "up",
+	"\x1b[B": "down",
+	"\x1b[C": "right",
+	"\x1b[D": "left",
+	"\r":     "ok",
+	"\n":     "ok",
+	"\x7f":   "back",
+	"\b":     "back",
+	"h":      "home",
+	"\x1b[H": "home",
+	"+":      "volumeup",
+	"=":      "volumeup",
+	"-":      "volumedown",
+	"_":      "volumedown",
+}
+
+// press maps terminal input onto the TV, returning the name of the action taken.
+func (r *remote) press(in string) (string, error) {
+	if name, ok := buttons[in]; ok {
+		return name, r.tv.PressButton(name)
+	}
+
+	switch in {
+	case "m":
+		r.mu.Lock()
+		muted := r.volume != nil && r.volume.Muted
+		r.mu.Unlock()
+
+		if muted {
+			return "unmute", r.tv.Unmute()
+		}
+		return "mute", r.tv.Mute()
+	case " ":
+		r.mu.Lock()
+		r.paused = !r.paused
+		paused := r.paused
+		r.mu.Unlock()
+
+		if paused {
+			return "pause", r.tv.Pause()
+		}
+		return "play", r.tv.Play()
+	default:
+		return "", nil
+	}
+}
+
+// render draws the status panel.
+func (r *remote) render() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	volume := "unknown"
+	if r.volume != nil {
+		volume = fmt.Sprintf("%d", r.volume.Volume)
+		if r.volume.Muted {
+			volume += " (muted)"
+		}
+	}
+
+	app := r.app
+	if app == "" {
+		app = "unknown"
+	}
+
+	last := r.last
+	if r.err != nil {
+		last += ": " + r.err.Error()
+	}
+
+	// the terminal is in raw mode, so lines must end with \r\n
+	lines := []string{
+		"webOS remote",
+		"",
+		"app:    " + app,
+		"volume: " + volume,
+		"last:   " + last,
+		"",
+		help,
+	}
+	fmt.Print("\x1b[2J\x1b[H" + strings.Join(lines, "\r\n") + "\r\n")
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/config"
+)
+
+// commands are the CLI subcommands by name.
+var commands = map[string]command{
+	"discover": {
+		usage:   "discover",
+		help:    "search the local network for TVs",
+		offline: true,
+		run:     discover,
+	},
+	"pair": {
+		usage:   "pair [-pin] <name> <address> [mac]",
+		help:    "pair with a TV and store its client key",
+		offline: true,
+		run:     pair,
+	},
+	"volume": {
+		usage: "volume [get | set <n> | up | down]",
+		help:  "get or change the volume",
+		run:   volume,
+	},
+	"mute": {
+		usage: "mute",
+		help:  "mute the audio output",
+		run: func(c *cli, args []string) (interface{}, error) {
+			return nil, c.tv.Mute()
+		},
+	},
+	"unmute": {
+		usage: "unmute",
+		help:  "unmute the audio output",
+		run: func(c *cli, args []string) (interface{}, error) {
+			return nil, c.tv.Unmute()
+		},
+	},
+	"apps": {
+		usage: "apps [list | current | launch <id> | close <id>]",
+		help:  "list, launch and close apps",
+		run:   apps,
+	},
+	"inputs": {
+		usage: "inputs [list | switch <id>]",
+		help:  "list and switch external inputs",
+		run:   inputs,
+	},
+	"channels": {
+		usage: "channels [list | current | up | down]",
+		help:  "list and change channels",
+		run:   channels,
+	},
+	"notify": {
+		usage: "notify <message>",
+		help:  "show a toast notification",
+		run: func(c *cli, args []string) (interface{}, error) {
+			if len(args) == 0 {
+				return nil, c.usageError()
+			}
+			return nil, c.tv.Notification(strings.Join(args, " "))
+		},
+	},
+	"screen": {
+		usage: "screen <on | off>",
+		help:  "turn the screen on or off, leaving the TV running",
+		run:   screen,
+	},
+	"power": {
+		usage:   "power <on | off>",
+		help:    "turn the TV on using Wake-on-LAN, or off",
+		offline: true,
+		run:     power,
+	},
+	"key": {
+		usage: "key <button>...",
+		help:  "press remote control buttons: " + strings.Join(webos.Buttons, ", "),
+		run:   key,
+	},
+}
+
+// discover searches for TVs.
+func discover(c *cli, args []string) (interface{}, error) {
+	return webos.Discover(3 * time.Second)
+}
+
+// pair pairs with a TV using the PROMPT or PIN method and stores the client key.
+func pair(c *cli, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("pair", flag.ContinueOnError)
+	pin := fs.Bool("pin", false, "pair using a PIN shown on the TV instead of a prompt")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := c.needArgs(fs.Args(), 2, 3); err != nil {
+		return nil, err
+	}
+
+	name, address := fs.Arg(0), fs.Arg(1)
+
+	d := &config.Device{Address: address, MAC: fs.Arg(2)}
+	if old, ok := c.cfg.Devices[name]; ok {
+		// keep the device's other settings, e.g. its aliases, and pair with its manifest
+		d.TLS, d.Manifest, d.Apps, d.Inputs = old.TLS, old.Manifest, old.Apps, old.Inputs
+	}
+
+	tv, err := c.cfg.DialDevice(d)
+	if err != nil {
+		return nil, err
+	}
+	defer tv.Close()
+
+	var key string
+	if *pin {
+		key, err = tv.AuthorisePIN(readPIN)
+	} else {
+		fmt.Fprintln(os.Stderr, "accept the prompt shown on the TV")
+		key, err = tv.AuthorisePrompt()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not pair: %w", err)
+	}
+
+	d.ClientKey, d.Fingerprint = key, tv.CertificateFingerprint()
+	c.cfg.Devices[name] = d
+	if c.cfg.Default == "" {
+		c.cfg.Default = name
+	}
+
+	if err := c.cfg.Save(c.configPath); err != nil {
+		return nil, fmt.Errorf("could not save configuration: %w", err)
+	}
+
+	return fmt.Sprintf("paired %s", name), nil
+}
+
+// readPIN reads the PIN shown on the TV from stdin.
+func readPIN() (string, error) {
+	fmt.Fprint(os.Stderr, "enter the PIN shown on the TV: ")
+	pin, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(pin), err
+}
+
+// volume gets or changes the volume.
+func volume(c *cli, args []string) (interface{}, error) {
+	if err := c.needArgs(args, 0, 2); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return c.tv.GetVolume()
+	}
+
+	switch normalise(args[0]) {
+	case "get":
+		return c.tv.GetVolume()
+	case "set":
+		if len(args) != 2 {
+			return nil, c.usageError()
+		}
+		v, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid volume %q", args[1])
+		}
+		return nil, c.tv.SetVolume(v)
+	case "up":
+		return nil, c.tv.VolumeUp()
+	case "down":
+		return nil, c.tv.VolumeDown()
+	default:
+		return nil, c.usageError()
+	}
+}
+
+// apps lists, launches and closes apps.
+func apps(c *cli, args []string) (interface{}, error) {
+	if err := c.needArgs(args, 0, 2); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return c.tv.ListApps()
+	}
+
+	switch normalise(args[0]) {
+	case "list":
+		return c.tv.ListApps()
+	case "current":
+		return c.tv.CurrentApp()
+	case "launch", "close":
+		if len(args) != 2 {
+			return nil, c.usageError()
+		}
+		if normalise(args[0]) == "launch" {
+			return nil, c.tv.LaunchApp(c.cfg.App(c.tvName, args[1]))
+		}
+		return nil, c.tv.CloseApp(c.cfg.App(c.tvName, args[1]))
+	default:
+		return nil, c.usageError()
+	}
+}
+
+// inputs lists and switches external inputs.
+func inputs(c *cli, args []string) (interface{}, error) {
+	if err := c.needArgs(args, 0, 2); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 || normalise(args[0]) == "list" {
+		return c.tv.ExternalInputs()
+	}
+
+	if normalise(args[0]) != "switch" || len(args) != 2 {
+		return nil, c.usageError()
+	}
+	return nil, c.tv.SwitchInput(c.cfg.Input(c.tvName, args[1]))
+}
+
+// channels lists and changes channels.
+func channels(c *cli, args []string) (interface{}, error) {
+	if err := c.needArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+
+	sub := "list"
+	if len(args) == 1 {
+		sub = normalise(args[0])
+	}
+
+	switch sub {
+	case "list":
+		msg, err := c.tv.ChannelList()
+		return msg.Payload, err
+	case "current":
+		msg, err := c.tv.CurrentChannel()
+		return msg.Payload, err
+	case "up":
+		return nil, c.tv.ChannelUp()
+	case "down":
+		return nil, c.tv.ChannelDown()
+	default:
+		return nil, c.usageError()
+	}
+}
+
+// screen turns the screen on or off.
+func screen(c *cli, args []string) (interface{}, error) {
+	if err := c.needArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	switch normalise(args[0]) {
+	case "on":
+		return nil, c.tv.ScreenOn()
+	case "off":
+		return nil, c.tv.ScreenOff()
+	default:
+		return nil, c.usageError()
+	}
+}
+
+// power turns the TV on using Wake-on-LAN, or off.
+func power(c *cli, args []string) (interface{}, error) {
+	if err := c.needArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	switch normalise(args[0]) {
+	case "on":
+		name, d, err := c.cfg.Device(c.tvName)
+		if err != nil {
+			return nil, err
+		}
+		if d.MAC == "" {
+			return nil, fmt.Errorf("no MAC address stored for %s, pair it again with its MAC address", name)
+		}
+		return nil, webos.WakeOnLAN(d.MAC)
+	case "off":
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+		defer c.tv.Close()
+		return nil, c.tv.Shutdown()
+	default:
+		return nil, c.usageError()
+	}
+}
+
+// key presses remote control keys in order.
+func key(c *cli, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, c.usageError()
+	}
+
+	for _, k := range args {
+		if err := c.tv.PressButton(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kaperys/go-webos/config"
+)
+
+// loadConfig reads the configuration file. A missing file returns an empty config.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config.New(), nil
+	}
+	return cfg, err
+}
@@ -0,0 +1,236 @@
+// Command webos controls webOS TVs from the command line.
+//
+//	webos discover
+//	webos pair living-room 192.168.1.67 a8:23:fe:00:00:00
+//	webos volume set 15
+//	webos -tv bedroom apps launch netflix
+//	webos -json apps list
+//
+// Paired TVs and their client keys are stored in the configuration file, see -config.
+// The file can be YAML or JSON, see the config package.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/config"
+)
+
+// cli holds the global flags and state shared by commands.
+type cli struct {
+	configPath string
+	tvName     string
+	address    string
+	json       bool
+
+	cfg   *config.Config
+	tv    *webos.TV
+	usage string
+}
+
+// command is a CLI subcommand.
+type command struct {
+	usage string
+	help  string
+
+	// offline commands don't connect to the TV.
+	offline bool
+	run     func(c *cli, args []string) (interface{}, error)
+}
+
+func main() {
+	c := &cli{}
+
+	flag.StringVar(&c.configPath, "config", config.DefaultPath(), "configuration file storing paired TVs")
+	flag.StringVar(&c.tvName, "tv", "", "name of the paired TV to control, defaults to the default TV")
+	flag.StringVar(&c.address, "addr", "", "address of the TV, overriding the paired address")
+	flag.BoolVar(&c.json, "json", false, "print results as JSON")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if err := c.run(cmd, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "webos: %v\n", err)
+
+		var perr *webos.PermissionError
+		if errors.As(err, &perr) {
+			fmt.Fprintf(os.Stderr, "the TV wasn't paired with the %s permission, set `manifest: full` in the config or the device's config and pair again\n", perr.Required)
+		}
+		os.Exit(1)
+	}
+}
+
+// usage prints the CLI usage.
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: webos [flags] <command> [arguments]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", commands[name].usage, commands[name].help)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// run loads the configuration, connects to the TV unless the command is offline,
+// and runs the command.
+func (c *cli) run(cmd command, args []string) error {
+	cfg, err := loadConfig(c.configPath)
+	if err != nil {
+		return err
+	}
+	c.cfg = cfg
+	c.usage = cmd.usage
+
+	if !cmd.offline {
+		if err := c.connect(); err != nil {
+			return err
+		}
+		defer c.tv.Close()
+	}
+
+	res, err := cmd.run(c, args)
+	if err != nil {
+		return err
+	}
+
+	return c.print(res)
+}
+
+// connect connects and authorises with the TV.
+func (c *cli) connect() error {
+	_, d, err := c.cfg.Device(c.tvName)
+	if err != nil && c.address == "" {
+		return err
+	}
+
+	if c.address != "" {
+		override := config.Device{Address: c.address}
+		if d != nil {
+			override = *d
+			override.Address = c.address
+		}
+		d = &override
+	}
+
+	tv, err := c.cfg.DialDevice(d)
+	if errors.Is(err, webos.ErrCertificateChanged) {
+		return fmt.Errorf("the TV's certificate has changed, pair it again if the TV was replaced: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tv.AuthoriseClientKey(d.ClientKey); err != nil {
+		tv.Close()
+		return fmt.Errorf("could not authorise, try pairing again: %w", err)
+	}
+
+	// TVs paired before certificates were pinned are pinned on first use
+	if c.address == "" && d.Fingerprint == "" && tv.CertificateFingerprint() != "" {
+		d.Fingerprint = tv.CertificateFingerprint()
+		if err := c.cfg.Save(c.configPath); err != nil {
+			tv.Close()
+			return fmt.Errorf("could not save configuration: %w", err)
+		}
+	}
+
+	c.tv = tv
+	return nil
+}
+
+// print prints the result of a command, either as JSON or as text.
+func (c *cli) print(res interface{}) error {
+	if res == nil {
+		return nil
+	}
+
+	if c.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer tw.Flush()
+
+	switch r := res.(type) {
+	case []webos.Device:
+		for _, d := range r {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Address, d.Name, d.Server)
+		}
+	case []webos.InstalledApp:
+		for _, a := range r {
+			fmt.Fprintf(tw, "%s\t%s\n", a.ID, a.Title)
+		}
+	case []webos.ExternalInput:
+		for _, i := range r {
+			fmt.Fprintf(tw, "%s\t%s\tconnected=%t\n", i.ID, i.Label, i.Connected)
+		}
+	case *webos.Volume:
+		fmt.Fprintf(tw, "volume\t%d\nmuted\t%t\n", r.Volume, r.Muted)
+	case *webos.App:
+		fmt.Fprintf(tw, "%s\n", r.AppID)
+	case webos.Payload:
+		keys := make([]string, 0, len(r))
+		for k := range r {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%s\t%v\n", k, r[k])
+		}
+	case string:
+		fmt.Fprintln(tw, r)
+	default:
+		fmt.Fprintf(tw, "%+v\n", r)
+	}
+
+	return nil
+}
+
+// needArgs returns a usage error unless args has between min and max arguments.
+func (c *cli) needArgs(args []string, min, max int) error {
+	if len(args) < min || len(args) > max {
+		return c.usageError()
+	}
+	return nil
+}
+
+// usageError returns an error showing the usage of the command being run.
+func (c *cli) usageError() error {
+	return fmt.Errorf("usage: webos %s", c.usage)
+}
+
+// normalise lower cases and trims s.
+func normalise(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -1,126 +1,59 @@
 package webos
 
-import (
-	"github.com/mitchellh/mapstructure"
-)
+// The Commands, their permissions and minimum webOS versions, and the endpoints of
+// the Commands without hand-written methods are generated from spec/ssap.yaml.
+//go:generate go run ./internal/ssapgen -spec spec/ssap.yaml -out commands_gen.go -stubs webostest/stubs_gen.go
 
 // Command is the type used by tv.Command to interact with the TV.
 type Command string
 
-const (
-	// APIServiceListCommand lists the API services available on the TV.
-	APIServiceListCommand Command = "ssap://api/getServiceList"
-
-	// ApplicationManagerForegroundAppCommand returns information about the forgeground app.
-	ApplicationManagerForegroundAppCommand Command = "ssap://com.webos.applicationManager/getForegroundAppInfo"
-
-	// AudioGetVolumeCommand returns information about the TV's configured audio output volume.
-	AudioGetVolumeCommand Command = "ssap://audio/getVolume"
-
-	// AudioSetVolumeCommand sets the TV's configured audio output volume.
-	AudioSetVolumeCommand Command = "ssap://audio/setVolume"
-
-	// AudioVolumeDownCommand decrements the TV's configured audio output volume.
-	AudioVolumeDownCommand Command = "ssap://audio/volumeDown"
-
-	// AudioVolumeStatusCommand returns information about the TV's configured audio output volume.
-	// Same as AudioGetVolumeCommand.
-	AudioVolumeStatusCommand Command = "ssap://audio/getVolume"
-
-	// AudioVolumeUpCommand increments the TV's configured audio output volume.
-	AudioVolumeUpCommand Command = "ssap://audio/volumeUp"
-
-	// AudioVolumeSetMuteCommand sets/toggles muting the TV's configured audio output.
-	AudioVolumeSetMuteCommand Command = "ssap://audio/setMute"
-
-	// MediaControlFastForwardCommand fast forwards the current media.
-	MediaControlFastForwardCommand Command = "ssap://media.controls/fastForward"
-
-	// MediaControlPauseCommand pauses the current media.
-	MediaControlPauseCommand Command = "ssap://media.controls/pause"
-
-	// MediaControlPlayCommand plays or resumes the current media.
-	MediaControlPlayCommand Command = "ssap://media.controls/play"
-
-	// MediaControlRewindCommand rewinds the current media.
-	MediaControlRewindCommand Command = "ssap://media.controls/rewind"
-
-	// MediaControlStopCommand stops the current media.
-	MediaControlStopCommand Command = "ssap://media.controls/stop"
-
-	// SystemLauncherCloseCommand closes a given application.
-	SystemLauncherCloseCommand Command = "ssap://system.launcher/close"
-
-	// SystemLauncherGetAppStateCommand returns information about the given application state.
-	SystemLauncherGetAppStateCommand Command = "ssap://system.launcher/getAppState"
-
-	// SystemLauncherLaunchCommand launches the given application.
-	SystemLauncherLaunchCommand Command = "ssap://system.launcher/launch"
-
-	// SystemLauncherOpenCommand opens a previously launched application.
-	SystemLauncherOpenCommand Command = "ssap://system.launcher/open"
-
-	// SystemNotificationsCreateToastCommand creates a "toast" notification.
-	SystemNotificationsCreateToastCommand Command = "ssap://system.notifications/createToast"
-
-	// SystemTurnOffCommand turns the TV off.
-	SystemTurnOffCommand Command = "ssap://system/turnOff"
-
-	// TVChannelDownCommand changes the channel down.
-	TVChannelDownCommand Command = "ssap://tv/channelDown"
-
-	// TVChannelListCommand returns information about the available channels.
-	TVChannelListCommand Command = "ssap://tv/getChannelList"
-
-	// TVChannelUpCommand changes the channel up.
-	TVChannelUpCommand Command = "ssap://tv/channelUp"
-
-	// TVCurrentChannelCommand returns information about the current channel.
-	TVCurrentChannelCommand Command = "ssap://tv/getCurrentChannel"
-
-	// TVCurrentChannelProgramCommand returns information about the current program playing on
-	// the current channel.
-	TVCurrentChannelProgramCommand Command = "ssap://tv/getChannelProgramInfo"
-
-	GetPointerInputSocketCommand Command = "ssap://com.webos.service.networkinput/getPointerInputSocket"
-
-	KeyEnterCommand Command = "ssap://com.webos.service.ime/sendEnterKey"
+// Permission returns the permission required to execute the Command, e.g.
+// "CONTROL_POWER", or an empty string if the Command doesn't require a permission.
+func (c Command) Permission() string {
+	return commandPermissions[c]
+}
+
+// Endpoints of the Commands with typed responses, which can be called with a context,
+// e.g. `webos.GetVolumeEndpoint.Call(ctx, tv, webos.Empty{})`.
+var (
+	ServiceListEndpoint    = Endpoint[Empty, ServiceList]{APIServiceListCommand, "ServiceList", serviceListSchemas}
+	CurrentAppEndpoint     = Endpoint[Empty, App]{ApplicationManagerForegroundAppCommand, "App", appSchemas}
+	ListAppsEndpoint       = Endpoint[Empty, AppList]{ApplicationManagerListAppsCommand, "AppList", appListSchemas}
+	GetVolumeEndpoint      = Endpoint[Empty, Volume]{AudioGetVolumeCommand, "Volume", volumeSchemas}
+	AppStatusEndpoint      = Endpoint[AppRequest, App]{SystemLauncherGetAppStateCommand, "App", appStateSchemas}
+	SystemInfoEndpoint     = Endpoint[Empty, SystemInfo]{SystemGetSystemInfoCommand, "SystemInfo", systemInfoSchemas}
+	SoftwareInfoEndpoint   = Endpoint[Empty, SoftwareInfo]{SoftwareInfoCommand, "SoftwareInfo", softwareInfoSchemas}
+	PowerStateEndpoint     = Endpoint[Empty, PowerState]{PowerStateCommand, "PowerState", powerStateSchemas}
+	ExternalInputsEndpoint = Endpoint[Empty, ExternalInputList]{TVExternalInputListCommand, "ExternalInputList", externalInputListSchemas}
 )
 
+// AppRequest is the request of the Commands taking an app ID.
+type AppRequest struct {
+	ID string `mapstructure:"id"`
+}
+
 // ServiceList returns information about the available services.
 func (tv *TV) ServiceList() (*ServiceList, error) {
-	msg, err := tv.Command(APIServiceListCommand, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	sl := &ServiceList{}
-	err = mapstructure.Decode(msg.Payload, sl)
-	return sl, err
+	return call(tv, ServiceListEndpoint, Empty{})
 }
 
 // CurrentApp returns information about the current app.
 func (tv *TV) CurrentApp() (*App, error) {
-	msg, err := tv.Command(ApplicationManagerForegroundAppCommand, nil)
-	if err != nil {
+	return call(tv, CurrentAppEndpoint, Empty{})
+}
+
+// ListApps returns information about the installed apps.
+func (tv *TV) ListApps() ([]InstalledApp, error) {
+	al, err := call(tv, ListAppsEndpoint, Empty{})
+	if al == nil {
 		return nil, err
 	}
-
-	a := &App{}
-	err = mapstructure.Decode(msg.Payload, a)
-	return a, err
+	return al.Apps, err
 }
 
 // GetVolume returns information about the audio output volume.
 func (tv *TV) GetVolume() (*Volume, error) {
-	msg, err := tv.Command(AudioGetVolumeCommand, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	v := &Volume{}
-	err = mapstructure.Decode(msg.Payload, v)
-	return v, err
+	return call(tv, GetVolumeEndpoint, Empty{})
 }
 
 // SetVolume sets the audio output volume to v.
@@ -137,14 +70,7 @@ func (tv *TV) VolumeDown() error {
 
 // VolumeStatus returns information about the audio output volume.
 func (tv *TV) VolumeStatus() (*Volume, error) {
-	msg, err := tv.Command(AudioVolumeStatusCommand, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	v := &Volume{}
-	err = mapstructure.Decode(msg.Payload, v)
-	return v, err
+	return call(tv, GetVolumeEndpoint, Empty{})
 }
 
 // VolumeUp increments the audio output volume.
@@ -203,14 +129,7 @@ func (tv *TV) CloseApp(app string) error {
 
 // AppStatus returns information about the given app status.
 func (tv *TV) AppStatus(app string) (*App, error) {
-	msg, err := tv.Command(SystemLauncherGetAppStateCommand, Payload{"id": app})
-	if err != nil {
-		return nil, err
-	}
-
-	a := &App{}
-	err = mapstructure.Decode(msg.Payload, a)
-	return a, err
+	return call(tv, AppStatusEndpoint, AppRequest{ID: app})
 }
 
 // LaunchApp launches an app.
@@ -237,6 +156,33 @@ func (tv *TV) Shutdown() error {
 	return err
 }
 
+// SystemInfo returns information about the TV model and features.
+func (tv *TV) SystemInfo() (*SystemInfo, error) {
+	return call(tv, SystemInfoEndpoint, Empty{})
+}
+
+// SoftwareInfo returns information about the TV software and firmware versions.
+func (tv *TV) SoftwareInfo() (*SoftwareInfo, error) {
+	return call(tv, SoftwareInfoEndpoint, Empty{})
+}
+
+// PowerState returns information about the TV's power state.
+func (tv *TV) PowerState() (*PowerState, error) {
+	return call(tv, PowerStateEndpoint, Empty{})
+}
+
+// ScreenOff turns the TV screen off.
+func (tv *TV) ScreenOff() error {
+	_, err := tv.Command(ScreenOffCommand, Payload{"standbyMode": "active"})
+	return err
+}
+
+// ScreenOn turns the TV screen on.
+func (tv *TV) ScreenOn() error {
+	_, err := tv.Command(ScreenOnCommand, Payload{"standbyMode": "active"})
+	return err
+}
+
 // ChannelDown decrements the current channel.
 func (tv *TV) ChannelDown() error {
 	_, err := tv.Command(TVChannelDownCommand, nil)
@@ -255,6 +201,21 @@ func (tv *TV) ChannelUp() error {
 	return err
 }
 
+// ExternalInputs returns information about the external inputs.
+func (tv *TV) ExternalInputs() ([]ExternalInput, error) {
+	il, err := call(tv, ExternalInputsEndpoint, Empty{})
+	if il == nil {
+		return nil, err
+	}
+	return il.Devices, err
+}
+
+// SwitchInput switches to the given external input, e.g. `HDMI_1`.
+func (tv *TV) SwitchInput(input string) error {
+	_, err := tv.Command(TVSwitchInputCommand, Payload{"inputId": input})
+	return err
+}
+
 // CurrentChannel returns information about the current channel.
 //  @todo implement a Channel type. This doesn't work on my TV.
 func (tv *TV) CurrentChannel() (Message, error) {
@@ -269,51 +230,35 @@ func (tv *TV) CurrentProgram() (Message, error) {
 }
 
 func (tv *TV) KeyUp() error {
-	if tv.input == nil {
-		var err error
-		tv.input, err = tv.createInput()
-		if err != nil {
-			return err
-		}
+	input, err := tv.Input()
+	if err != nil {
+		return err
 	}
-	tv.input.SendButton("UP")
-	return nil
+	return input.SendButton("UP")
 }
 
 func (tv *TV) KeyDown() error {
-	if tv.input == nil {
-		var err error
-		tv.input, err = tv.createInput()
-		if err != nil {
-			return err
-		}
+	input, err := tv.Input()
+	if err != nil {
+		return err
 	}
-	tv.input.SendButton("DOWN")
-	return nil
+	return input.SendButton("DOWN")
 }
 
 func (tv *TV) KeyLeft() error {
-	if tv.input == nil {
-		var err error
-		tv.input, err = tv.createInput()
-		if err != nil {
-			return err
-		}
+	input, err := tv.Input()
+	if err != nil {
+		return err
 	}
-	tv.input.SendButton("LEFT")
-	return nil
+	return input.SendButton("LEFT")
 }
 
 func (tv *TV) KeyRight() error {
-	if tv.input == nil {
-		var err error
-		tv.input, err = tv.createInput()
-		if err != nil {
-			return err
-		}
+	input, err := tv.Input()
+	if err != nil {
+		return err
 	}
-	tv.input.SendButton("RIGHT")
-	return nil
+	return input.SendButton("RIGHT")
 }
 
 func (tv *TV) KeyOk() (Message, error) {
@@ -321,25 +266,17 @@ func (tv *TV) KeyOk() (Message, error) {
 }
 
 func (tv *TV) KeyBack() error {
-	if tv.input == nil {
-		var err error
-		tv.input, err = tv.createInput()
-		if err != nil {
-			return err
-		}
+	input, err := tv.Input()
+	if err != nil {
+		return err
 	}
-	tv.input.SendButton("BACK")
-	return nil
+	return input.SendButton("BACK")
 }
 
 func (tv *TV) KeyHome() error {
-	if tv.input == nil {
-		var err error
-		tv.input, err = tv.createInput()
-		if err != nil {
-			return err
-		}
+	input, err := tv.Input()
+	if err != nil {
+		return err
 	}
-	tv.input.SendButton("HOME")
-	return nil
+	return input.SendButton("HOME")
 }
@@ -0,0 +1,367 @@
+// Code generated by ssapgen from spec/ssap.yaml. DO NOT EDIT.
+
+package webos
+
+const (
+	// APIServiceListCommand lists the API services available on the TV.
+	APIServiceListCommand Command = "ssap://api/getServiceList"
+
+	// ApplicationManagerForegroundAppCommand returns information about the forgeground app.
+	ApplicationManagerForegroundAppCommand Command = "ssap://com.webos.applicationManager/getForegroundAppInfo"
+
+	// ApplicationManagerListAppsCommand lists the apps installed on the TV.
+	ApplicationManagerListAppsCommand Command = "ssap://com.webos.applicationManager/listApps"
+
+	// ApplicationManagerListLaunchPointsCommand lists the launch points shown in the TV's
+	// launcher, the apps and inputs which can be launched.
+	ApplicationManagerListLaunchPointsCommand Command = "ssap://com.webos.applicationManager/listLaunchPoints"
+
+	// AudioGetVolumeCommand returns information about the TV's configured audio output
+	// volume.
+	AudioGetVolumeCommand Command = "ssap://audio/getVolume"
+
+	// AudioSetVolumeCommand sets the TV's configured audio output volume.
+	AudioSetVolumeCommand Command = "ssap://audio/setVolume"
+
+	// AudioVolumeDownCommand decrements the TV's configured audio output volume.
+	AudioVolumeDownCommand Command = "ssap://audio/volumeDown"
+
+	// AudioVolumeStatusCommand returns information about the TV's configured audio output
+	// volume. Same as AudioGetVolumeCommand.
+	AudioVolumeStatusCommand Command = "ssap://audio/getVolume"
+
+	// AudioVolumeUpCommand increments the TV's configured audio output volume.
+	AudioVolumeUpCommand Command = "ssap://audio/volumeUp"
+
+	// AudioVolumeSetMuteCommand sets/toggles muting the TV's configured audio output.
+	AudioVolumeSetMuteCommand Command = "ssap://audio/setMute"
+
+	// AudioGetStatusCommand returns the TV's audio status, including the volume and whether
+	// it is muted.
+	AudioGetStatusCommand Command = "ssap://audio/getStatus"
+
+	// AudioGetSoundOutputCommand returns the sound output, e.g. `tv_speaker` or
+	// `external_arc`.
+	AudioGetSoundOutputCommand Command = "ssap://com.webos.service.apiadapter/audio/getSoundOutput"
+
+	// AudioChangeSoundOutputCommand changes the sound output.
+	AudioChangeSoundOutputCommand Command = "ssap://com.webos.service.apiadapter/audio/changeSoundOutput"
+
+	// MediaForegroundAppCommand returns information about the media playing in the
+	// foreground app.
+	MediaForegroundAppCommand Command = "ssap://com.webos.media/getForegroundAppInfo"
+
+	// MediaControlFastForwardCommand fast forwards the current media.
+	MediaControlFastForwardCommand Command = "ssap://media.controls/fastForward"
+
+	// MediaControlPauseCommand pauses the current media.
+	MediaControlPauseCommand Command = "ssap://media.controls/pause"
+
+	// MediaControlPlayCommand plays or resumes the current media.
+	MediaControlPlayCommand Command = "ssap://media.controls/play"
+
+	// MediaControlRewindCommand rewinds the current media.
+	MediaControlRewindCommand Command = "ssap://media.controls/rewind"
+
+	// MediaControlStopCommand stops the current media.
+	MediaControlStopCommand Command = "ssap://media.controls/stop"
+
+	// MediaViewerOpenCommand opens the given media URL in the TV's media player.
+	MediaViewerOpenCommand Command = "ssap://media.viewer/open"
+
+	// MediaViewerCloseCommand closes the given media player session.
+	MediaViewerCloseCommand Command = "ssap://media.viewer/close"
+
+	// PairingSetPINCommand sends the PIN displayed by the TV during PIN pairing.
+	PairingSetPINCommand Command = "ssap://pairing/setPin"
+
+	// SystemLauncherCloseCommand closes a given application.
+	SystemLauncherCloseCommand Command = "ssap://system.launcher/close"
+
+	// SystemLauncherGetAppStateCommand returns information about the given application
+	// state.
+	SystemLauncherGetAppStateCommand Command = "ssap://system.launcher/getAppState"
+
+	// SystemLauncherLaunchCommand launches the given application.
+	SystemLauncherLaunchCommand Command = "ssap://system.launcher/launch"
+
+	// SystemLauncherOpenCommand opens a previously launched application.
+	SystemLauncherOpenCommand Command = "ssap://system.launcher/open"
+
+	// WebAppLaunchCommand launches the given web app.
+	WebAppLaunchCommand Command = "ssap://webapp/launchWebApp"
+
+	// WebAppCloseCommand closes the given web app.
+	WebAppCloseCommand Command = "ssap://webapp/closeWebApp"
+
+	// WebAppPinCommand pins the given web app, keeping it running in the background.
+	WebAppPinCommand Command = "ssap://webapp/pinWebApp"
+
+	// WebAppIsPinnedCommand returns whether the given web app is pinned.
+	WebAppIsPinnedCommand Command = "ssap://webapp/isWebAppPinned"
+
+	// SystemNotificationsCreateToastCommand creates a "toast" notification.
+	SystemNotificationsCreateToastCommand Command = "ssap://system.notifications/createToast"
+
+	// SystemNotificationsCreateAlertCommand creates an alert with buttons, which may call
+	// luna URIs when pressed or when the alert is closed.
+	SystemNotificationsCreateAlertCommand Command = "ssap://system.notifications/createAlert"
+
+	// SystemNotificationsCloseAlertCommand closes the given alert.
+	SystemNotificationsCloseAlertCommand Command = "ssap://system.notifications/closeAlert"
+
+	// SettingsGetSystemSettingsCommand returns the given settings of a category, e.g.
+	// `picture`.
+	SettingsGetSystemSettingsCommand Command = "ssap://settings/getSystemSettings"
+
+	// SystemGetSystemInfoCommand returns information about the TV model and features.
+	SystemGetSystemInfoCommand Command = "ssap://system/getSystemInfo"
+
+	// SystemTurnOffCommand turns the TV off.
+	SystemTurnOffCommand Command = "ssap://system/turnOff"
+
+	// PowerStateCommand returns information about the TV's power state.
+	PowerStateCommand Command = "ssap://com.webos.service.tvpower/power/getPowerState"
+
+	// ScreenOffCommand turns the TV screen off, leaving the TV running.
+	ScreenOffCommand Command = "ssap://com.webos.service.tvpower/power/turnOffScreen"
+
+	// ScreenOnCommand turns the TV screen back on.
+	ScreenOnCommand Command = "ssap://com.webos.service.tvpower/power/turnOnScreen"
+
+	// SoftwareInfoCommand returns information about the TV software and firmware versions.
+	SoftwareInfoCommand Command = "ssap://com.webos.service.update/getCurrentSWInformation"
+
+	// TVDisplayGet3DStatusCommand returns whether 3D is on, and its pattern.
+	TVDisplayGet3DStatusCommand Command = "ssap://com.webos.service.tv.display/get3DStatus"
+
+	// TVDisplaySet3DOnCommand turns 3D on.
+	TVDisplaySet3DOnCommand Command = "ssap://com.webos.service.tv.display/set3DOn"
+
+	// TVDisplaySet3DOffCommand turns 3D off.
+	TVDisplaySet3DOffCommand Command = "ssap://com.webos.service.tv.display/set3DOff"
+
+	// TVChannelDownCommand changes the channel down.
+	TVChannelDownCommand Command = "ssap://tv/channelDown"
+
+	// TVChannelListCommand returns information about the available channels.
+	TVChannelListCommand Command = "ssap://tv/getChannelList"
+
+	// TVChannelUpCommand changes the channel up.
+	TVChannelUpCommand Command = "ssap://tv/channelUp"
+
+	// TVOpenChannelCommand changes to the given channel.
+	TVOpenChannelCommand Command = "ssap://tv/openChannel"
+
+	// TVExternalInputListCommand returns information about the external inputs, e.g. HDMI
+	// ports.
+	TVExternalInputListCommand Command = "ssap://tv/getExternalInputList"
+
+	// TVSwitchInputCommand switches to the given external input.
+	TVSwitchInputCommand Command = "ssap://tv/switchInput"
+
+	// TVCurrentChannelCommand returns information about the current channel.
+	TVCurrentChannelCommand Command = "ssap://tv/getCurrentChannel"
+
+	// TVCurrentChannelProgramCommand returns information about the current program playing
+	// on the current channel.
+	TVCurrentChannelProgramCommand Command = "ssap://tv/getChannelProgramInfo"
+
+	// TVChannelCurrentProgramCommand returns information about the program currently playing
+	// on the current channel, without the rest of its schedule.
+	TVChannelCurrentProgramCommand Command = "ssap://tv/getChannelCurrentProgramInfo"
+
+	// TVGetCurrentTimeCommand returns the TV's current time.
+	TVGetCurrentTimeCommand Command = "ssap://com.webos.service.tv.time/getCurrentTime"
+
+	// GetPointerInputSocketCommand returns the path of the pointer input socket, used to
+	// send button presses and pointer moves.
+	GetPointerInputSocketCommand Command = "ssap://com.webos.service.networkinput/getPointerInputSocket"
+
+	// ConnectionManagerGetInfoCommand returns information about the TV's network
+	// connections.
+	ConnectionManagerGetInfoCommand Command = "ssap://com.webos.service.connectionmanager/getinfo"
+
+	// IMERegisterRemoteKeyboardCommand subscribes to the state of the on-screen keyboard,
+	// e.g. whether a text field is focused.
+	IMERegisterRemoteKeyboardCommand Command = "ssap://com.webos.service.ime/registerRemoteKeyboard"
+
+	// KeyEnterCommand presses the enter key of the on-screen keyboard.
+	KeyEnterCommand Command = "ssap://com.webos.service.ime/sendEnterKey"
+
+	// IMEInsertTextCommand types text into the focused text field.
+	IMEInsertTextCommand Command = "ssap://com.webos.service.ime/insertText"
+
+	// IMEDeleteCharactersCommand deletes characters before the cursor in the focused text
+	// field.
+	IMEDeleteCharactersCommand Command = "ssap://com.webos.service.ime/deleteCharacters"
+)
+
+// commandPermissions are the permissions required to execute Commands.
+var commandPermissions = map[Command]string{
+	ApplicationManagerForegroundAppCommand:    "READ_RUNNING_APPS",
+	ApplicationManagerListAppsCommand:         "READ_INSTALLED_APPS",
+	ApplicationManagerListLaunchPointsCommand: "READ_INSTALLED_APPS",
+	AudioGetVolumeCommand:                     "CONTROL_AUDIO",
+	AudioSetVolumeCommand:                     "CONTROL_AUDIO",
+	AudioVolumeDownCommand:                    "CONTROL_AUDIO",
+	AudioVolumeUpCommand:                      "CONTROL_AUDIO",
+	AudioVolumeSetMuteCommand:                 "CONTROL_AUDIO",
+	AudioGetStatusCommand:                     "CONTROL_AUDIO",
+	AudioGetSoundOutputCommand:                "CONTROL_AUDIO",
+	AudioChangeSoundOutputCommand:             "CONTROL_AUDIO",
+	MediaForegroundAppCommand:                 "READ_RUNNING_APPS",
+	MediaControlFastForwardCommand:            "CONTROL_INPUT_MEDIA_PLAYBACK",
+	MediaControlPauseCommand:                  "CONTROL_INPUT_MEDIA_PLAYBACK",
+	MediaControlPlayCommand:                   "CONTROL_INPUT_MEDIA_PLAYBACK",
+	MediaControlRewindCommand:                 "CONTROL_INPUT_MEDIA_PLAYBACK",
+	MediaControlStopCommand:                   "CONTROL_INPUT_MEDIA_PLAYBACK",
+	MediaViewerOpenCommand:                    "LAUNCH",
+	MediaViewerCloseCommand:                   "CLOSE",
+	SystemLauncherCloseCommand:                "CLOSE",
+	SystemLauncherGetAppStateCommand:          "READ_APP_STATUS",
+	SystemLauncherLaunchCommand:               "LAUNCH",
+	SystemLauncherOpenCommand:                 "LAUNCH",
+	WebAppLaunchCommand:                       "LAUNCH_WEBAPP",
+	WebAppCloseCommand:                        "LAUNCH_WEBAPP",
+	WebAppPinCommand:                          "LAUNCH_WEBAPP",
+	WebAppIsPinnedCommand:                     "LAUNCH_WEBAPP",
+	SystemNotificationsCreateToastCommand:     "WRITE_NOTIFICATION_TOAST",
+	SystemNotificationsCreateAlertCommand:     "WRITE_NOTIFICATION_ALERT",
+	SystemNotificationsCloseAlertCommand:      "WRITE_NOTIFICATION_ALERT",
+	SystemTurnOffCommand:                      "CONTROL_POWER",
+	PowerStateCommand:                         "READ_POWER_STATE",
+	ScreenOffCommand:                          "CONTROL_POWER",
+	ScreenOnCommand:                           "CONTROL_POWER",
+	SoftwareInfoCommand:                       "READ_UPDATE_INFO",
+	TVChannelDownCommand:                      "CONTROL_INPUT_TV",
+	TVChannelListCommand:                      "READ_TV_CHANNEL_LIST",
+	TVChannelUpCommand:                        "CONTROL_INPUT_TV",
+	TVOpenChannelCommand:                      "CONTROL_INPUT_TV",
+	TVExternalInputListCommand:                "READ_INPUT_DEVICE_LIST",
+	TVSwitchInputCommand:                      "CONTROL_INPUT_TV",
+	TVCurrentChannelCommand:                   "READ_CURRENT_CHANNEL",
+	TVCurrentChannelProgramCommand:            "READ_CURRENT_CHANNEL",
+	TVChannelCurrentProgramCommand:            "READ_CURRENT_CHANNEL",
+	TVGetCurrentTimeCommand:                   "READ_TV_CURRENT_TIME",
+	GetPointerInputSocketCommand:              "CONTROL_INPUT_JOYSTICK",
+	ConnectionManagerGetInfoCommand:           "READ_NETWORK_STATE",
+	IMERegisterRemoteKeyboardCommand:          "CONTROL_INPUT_TEXT",
+	KeyEnterCommand:                           "CONTROL_INPUT_TEXT",
+	IMEInsertTextCommand:                      "CONTROL_INPUT_TEXT",
+	IMEDeleteCharactersCommand:                "CONTROL_INPUT_TEXT",
+}
+
+// minimumVersions are the first webOS major versions supporting Commands.
+var minimumVersions = map[Command]int{
+	AudioGetSoundOutputCommand:    3,
+	AudioChangeSoundOutputCommand: 3,
+	ScreenOffCommand:              4,
+	ScreenOnCommand:               4,
+}
+
+// commandFeatures are the system information features required by Commands.
+var commandFeatures = map[Command]string{
+	TVDisplayGet3DStatusCommand: "3d",
+	TVDisplaySet3DOnCommand:     "3d",
+	TVDisplaySet3DOffCommand:    "3d",
+}
+
+// TVTime is a date and time of the TV's clock.
+type TVTime struct {
+	Year   int `mapstructure:"year" json:"year"`
+	Month  int `mapstructure:"month" json:"month"`
+	Day    int `mapstructure:"day" json:"day"`
+	Hour   int `mapstructure:"hour" json:"hour"`
+	Minute int `mapstructure:"minute" json:"minute"`
+	Second int `mapstructure:"second" json:"second"`
+}
+
+// AudioStatus is the response of AudioGetStatusCommand.
+type AudioStatus struct {
+	Volume   int    `mapstructure:"volume" json:"volume"`
+	Mute     bool   `mapstructure:"mute" json:"mute"`
+	Scenario string `mapstructure:"scenario" json:"scenario"`
+}
+
+// SoundOutput is the response of AudioGetSoundOutputCommand.
+type SoundOutput struct {
+	Output string `mapstructure:"soundOutput" json:"soundOutput"`
+}
+
+// SetSoundOutputRequest is the request of AudioChangeSoundOutputCommand.
+type SetSoundOutputRequest struct {
+	Output string `mapstructure:"output" json:"output"`
+}
+
+// OpenChannelRequest is the request of TVOpenChannelCommand.
+type OpenChannelRequest struct {
+	ChannelID string `mapstructure:"channelId" json:"channelId"`
+}
+
+// CurrentTime is the response of TVGetCurrentTimeCommand.
+type CurrentTime struct {
+	Time TVTime `mapstructure:"time" json:"time"`
+}
+
+// InsertTextRequest is the request of IMEInsertTextCommand.
+type InsertTextRequest struct {
+	Text    string `mapstructure:"text" json:"text"`
+	Replace bool   `mapstructure:"replace" json:"replace"`
+}
+
+// DeleteCharactersRequest is the request of IMEDeleteCharactersCommand.
+type DeleteCharactersRequest struct {
+	Count int `mapstructure:"count" json:"count"`
+}
+
+// Endpoints of the Commands with generated methods.
+var (
+	AudioStatusEndpoint      = NewEndpoint[Empty, AudioStatus](AudioGetStatusCommand)
+	SoundOutputEndpoint      = NewEndpoint[Empty, SoundOutput](AudioGetSoundOutputCommand)
+	SetSoundOutputEndpoint   = NewEndpoint[SetSoundOutputRequest, Empty](AudioChangeSoundOutputCommand)
+	OpenChannelEndpoint      = NewEndpoint[OpenChannelRequest, Empty](TVOpenChannelCommand)
+	CurrentTimeEndpoint      = NewEndpoint[Empty, CurrentTime](TVGetCurrentTimeCommand)
+	InsertTextEndpoint       = NewEndpoint[InsertTextRequest, Empty](IMEInsertTextCommand)
+	DeleteCharactersEndpoint = NewEndpoint[DeleteCharactersRequest, Empty](IMEDeleteCharactersCommand)
+)
+
+// AudioStatus returns the volume and whether the audio output is muted.
+func (tv *TV) AudioStatus() (*AudioStatus, error) {
+	return call(tv, AudioStatusEndpoint, Empty{})
+}
+
+// SoundOutput returns the sound output, e.g. `tv_speaker` or `external_arc`.
+func (tv *TV) SoundOutput() (*SoundOutput, error) {
+	return call(tv, SoundOutputEndpoint, Empty{})
+}
+
+// SetSoundOutput changes the sound output, e.g. to `external_arc`.
+func (tv *TV) SetSoundOutput(output string) error {
+	_, err := call(tv, SetSoundOutputEndpoint, SetSoundOutputRequest{Output: output})
+	return err
+}
+
+// OpenChannel changes to the channel with the ID, as listed by ChannelList.
+func (tv *TV) OpenChannel(channelID string) error {
+	_, err := call(tv, OpenChannelEndpoint, OpenChannelRequest{ChannelID: channelID})
+	return err
+}
+
+// CurrentTime returns the TV's current time, e.g. to check its clock.
+func (tv *TV) CurrentTime() (*CurrentTime, error) {
+	return call(tv, CurrentTimeEndpoint, Empty{})
+}
+
+// InsertText types the text into the focused text field, replacing its contents if
+// replace is true.
+func (tv *TV) InsertText(text string, replace bool) error {
+	_, err := call(tv, InsertTextEndpoint, InsertTextRequest{Text: text, Replace: replace})
+	return err
+}
+
+// DeleteCharacters deletes count characters before the cursor in the focused text field.
+func (tv *TV) DeleteCharacters(count int) error {
+	_, err := call(tv, DeleteCharactersEndpoint, DeleteCharactersRequest{Count: count})
+	return err
+}
@@ -0,0 +1,458 @@
+// Package config loads the configuration of one or more TVs from a YAML or JSON file,
+// and connects to them. The same file is used by the webos, webos-gateway and
+// webos-mqtt commands.
+//
+//	default: living-room
+//	tls: insecure
+//	manifest: full
+//	timeouts:
+//	  dial: 5s
+//	  request: 10s
+//	  keepalive: 30s
+//	apps:
+//	  netflix: netflix
+//	  youtube: youtube.leanback.v4
+//	devices:
+//	  living-room:
+//	    address: 192.168.1.67
+//	    mac: a8:23:fe:00:00:00
+//	    client_key: 6c7b2ec679ffd1c2736abd621153eabb
+//	    inputs:
+//	      console: HDMI_2
+//	  bedroom:
+//	    address: 192.168.1.68
+//	    tls: none
+//
+// Connect returns a TV ready to use:
+//
+//	cfg, err := config.Load(path)
+//	if err != nil {
+//		...
+//	}
+//	tv, err := cfg.Connect("living-room")
+package config
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// TLSMode is how the connection to the TV is secured.
+type TLSMode = webos.TLSMode
+
+// The TLSModes, see webos.TLSMode.
+const (
+	TLSInsecure = webos.TLSInsecure
+	TLSVerify   = webos.TLSVerify
+	TLSNone     = webos.TLSNone
+)
+
+// Duration is a time.Duration written as a string in the configuration file, e.g. "5s".
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// Timeouts are the timeouts used to connect to and make requests to the TVs. Zero
+// values use the defaults.
+type Timeouts struct {
+	// Dial is the timeout to open the TCP connection. It defaults to 5s.
+	Dial Duration `json:"dial,omitempty" yaml:"dial,omitempty"`
+
+	// Handshake is the timeout of the websocket handshake. It defaults to 10s.
+	Handshake Duration `json:"handshake,omitempty" yaml:"handshake,omitempty"`
+
+	// Request is how long to wait for the TV to respond to a request. It defaults to
+	// webos.DefaultTimeout.
+	Request Duration `json:"request,omitempty" yaml:"request,omitempty"`
+
+	// KeepAlive is how often a Manager checks the connection to each TV, reconnecting
+	// to TVs whose connection is lost. Connections aren't checked if it's zero.
+	KeepAlive Duration `json:"keepalive,omitempty" yaml:"keepalive,omitempty"`
+}
+
+// Config is the configuration file.
+type Config struct {
+	// Default is the name of the device used when none is given.
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// TLS is the TLSMode of the devices which don't set their own.
+	TLS TLSMode `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// Manifest is the name of the webos.ManifestPresets requested when pairing by the
+	// devices which don't set their own. It defaults to webos.DefaultManifestPreset.
+	Manifest string `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+
+	Timeouts Timeouts `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
+
+	// Apps are aliases of app IDs, e.g. "youtube: youtube.leanback.v4", shared by
+	// every device.
+	Apps map[string]string `json:"apps,omitempty" yaml:"apps,omitempty"`
+
+	// Inputs are aliases of external input IDs, e.g. "console: HDMI_2", shared by
+	// every device.
+	Inputs map[string]string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+
+	// Devices are the TVs by name.
+	Devices map[string]*Device `json:"devices" yaml:"devices"`
+}
+
+// Device is the configuration of a TV. Its TLS mode and manifest override the Config's,
+// and its Apps and Inputs aliases override the Config's aliases.
+type Device = webos.DeviceConfig
+
+// New returns an empty Config.
+func New() *Config {
+	return &Config{Devices: make(map[string]*Device)}
+}
+
+// Parse parses and validates a YAML or JSON configuration. The devices of
+// configuration files written by older versions of the webos command, under "tvs",
+// are read too.
+func Parse(b []byte) (*Config, error) {
+	var f struct {
+		Config `yaml:",inline"`
+		TVs    map[string]*Device `yaml:"tvs"`
+	}
+
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	cfg := &f.Config
+	if cfg.Devices == nil {
+		cfg.Devices = make(map[string]*Device, len(f.TVs))
+	}
+	for name, d := range f.TVs {
+		if _, ok := cfg.Devices[name]; !ok {
+			cfg.Devices[name] = d
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// DefaultPath returns the path of the configuration file the commands store paired
+// TVs in, in the user's configuration directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "webos.json"
+	}
+	return filepath.Join(dir, "webos", "config.json")
+}
+
+// Load reads, parses and validates the configuration file. A missing file returns an
+// error which can be tested using errors.Is with os.ErrNotExist.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("could not load %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration file, as JSON if the path ends with ".json" and YAML
+// otherwise. The file contains client keys, so it is only readable by the user.
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	var b []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		b, err = json.MarshalIndent(c, "", "  ")
+		b = append(b, '\n')
+	} else {
+		b, err = yaml.Marshal(c)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0600)
+}
+
+// Validate checks the configuration, returning the first problem found.
+func (c *Config) Validate() error {
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+
+	if c.Manifest != "" {
+		if _, ok := webos.ManifestPresets[c.Manifest]; !ok {
+			return errors.Errorf("unknown manifest preset: %s", c.Manifest)
+		}
+	}
+
+	for name, d := range map[string]Duration{
+		"dial":      c.Timeouts.Dial,
+		"handshake": c.Timeouts.Handshake,
+		"request":   c.Timeouts.Request,
+		"keepalive": c.Timeouts.KeepAlive,
+	} {
+		if d < 0 {
+			return errors.Errorf("negative %s timeout: %s", name, time.Duration(d))
+		}
+	}
+
+	if c.Default != "" {
+		if _, ok := c.Devices[c.Default]; !ok {
+			return errors.Errorf("unknown default device: %s", c.Default)
+		}
+	}
+
+	for _, name := range c.Names() {
+		d := c.Devices[name]
+		if d == nil {
+			return errors.Errorf("device %s: no configuration", name)
+		}
+		if err := d.Validate(); err != nil {
+			return errors.Wrapf(err, "device %s", name)
+		}
+		if d.Fingerprint != "" && c.tlsMode(d) == TLSNone {
+			return errors.Errorf("device %s: fingerprint can't be verified with TLS mode none", name)
+		}
+	}
+	return nil
+}
+
+// Names returns the sorted names of the devices.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Devices))
+	for name := range c.Devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Device returns the named device, or the default device if name is empty. If there's
+// no default device and only one device, it is the default.
+func (c *Config) Device(name string) (string, *Device, error) {
+	if name == "" {
+		name = c.Default
+	}
+
+	if name == "" {
+		if len(c.Devices) != 1 {
+			return "", nil, errors.New("no device given and no default device")
+		}
+		for n := range c.Devices {
+			name = n
+		}
+	}
+
+	d, ok := c.Devices[name]
+	if !ok {
+		return "", nil, errors.Errorf("unknown device: %s", name)
+	}
+	return name, d, nil
+}
+
+// App returns the app ID of the alias for the named device, or the alias itself if it
+// isn't an alias.
+func (c *Config) App(name, alias string) string {
+	return lookup(c.AppAliases(name), alias)
+}
+
+// Input returns the external input ID of the alias for the named device, or the alias
+// itself if it isn't an alias.
+func (c *Config) Input(name, alias string) string {
+	return lookup(c.InputAliases(name), alias)
+}
+
+// AppAliases returns the app aliases of the named device, including the shared aliases
+// it doesn't override.
+func (c *Config) AppAliases(name string) map[string]string {
+	return c.aliases(name, c.Apps, func(d *Device) map[string]string { return d.Apps })
+}
+
+// InputAliases returns the external input aliases of the named device, including the
+// shared aliases it doesn't override.
+func (c *Config) InputAliases(name string) map[string]string {
+	return c.aliases(name, c.Inputs, func(d *Device) map[string]string { return d.Inputs })
+}
+
+// aliases merges the shared aliases with the device's aliases.
+func (c *Config) aliases(name string, shared map[string]string, device func(*Device) map[string]string) map[string]string {
+	aliases := make(map[string]string, len(shared))
+	for alias, id := range shared {
+		aliases[alias] = id
+	}
+
+	if _, d, err := c.Device(name); err == nil {
+		for alias, id := range device(d) {
+			aliases[alias] = id
+		}
+	}
+	return aliases
+}
+
+// lookup returns the ID of the alias, or the alias itself if it isn't an alias.
+func lookup(aliases map[string]string, alias string) string {
+	if id, ok := aliases[alias]; ok {
+		return id
+	}
+	return alias
+}
+
+// tlsMode returns the device's TLSMode.
+func (c *Config) tlsMode(d *Device) TLSMode {
+	switch {
+	case d.TLS != "":
+		return d.TLS
+	case c.TLS != "":
+		return c.TLS
+	default:
+		return TLSInsecure
+	}
+}
+
+// manifest returns the name of the device's ManifestPreset.
+func (c *Config) manifest(d *Device) string {
+	if d.Manifest != "" {
+		return d.Manifest
+	}
+	return c.Manifest
+}
+
+// Dial connects to the named device, or the default device if name is empty, and
+// starts the MessageHandler. The TV isn't authorised, so it can be paired.
+func (c *Config) Dial(name string) (*webos.TV, error) {
+	_, d, err := c.Device(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.DialDevice(d)
+}
+
+// DialDevice connects to the device using the Config's settings, e.g. to pair with a
+// TV which isn't in the Config yet, and starts the MessageHandler. Devices without a
+// fingerprint don't fall back to ws, as described by webos.DeviceConfig.DialOptions.
+func (c *Config) DialDevice(d *Device) (*webos.TV, error) {
+	tv, err := c.dial(d)
+	if errors.Is(err, syscall.ECONNREFUSED) && d.Fingerprint == "" && c.tlsMode(d) == TLSInsecure {
+		return nil, errors.Wrap(err, "the TV refused the wss connection, use the TLS mode none for TVs which only support ws")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go tv.MessageHandler()
+
+	return tv, nil
+}
+
+// dial connects to the device. The MessageHandler isn't started.
+func (c *Config) dial(d *Device) (*webos.TV, error) {
+	opts := []webos.Option{
+		webos.WithTimeout(time.Duration(c.Timeouts.Request)),
+		webos.WithDialer(&websocket.Dialer{
+			HandshakeTimeout: orDefault(c.Timeouts.Handshake, 10*time.Second),
+			// the TV uses a self-signed certificate
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			NetDial:         (&net.Dialer{Timeout: orDefault(c.Timeouts.Dial, 5*time.Second)}).Dial,
+		}),
+	}
+
+	resolved := *d
+	resolved.TLS = c.tlsMode(d)
+	opts = append(opts, resolved.DialOptions()...)
+
+	tv, err := webos.Dial(d.Address, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest := c.manifest(d); manifest != "" {
+		if err := tv.SetManifestPreset(manifest); err != nil {
+			tv.Close()
+			return nil, err
+		}
+	}
+	return tv, nil
+}
+
+// Connect connects and authorises with the named device, or the default device if
+// name is empty, using its client key.
+func (c *Config) Connect(name string) (*webos.TV, error) {
+	name, d, err := c.Device(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tv, err := c.dial(d)
+	if err != nil {
+		return nil, err
+	}
+
+	go tv.MessageHandler()
+
+	if err := tv.AuthoriseClientKey(d.ClientKey); err != nil {
+		tv.Close()
+		return nil, errors.Wrapf(err, "could not authorise with %s", name)
+	}
+	return tv, nil
+}
+
+// Manager returns a webos.Manager for the devices, which are dialed using the
+// configuration, checking the connections every Timeouts.KeepAlive. Devices added to
+// the Manager later use the Config's settings.
+func (c *Config) Manager() *webos.Manager {
+	devices := make(map[string]webos.DeviceConfig, len(c.Devices))
+	for name, d := range c.Devices {
+		devices[name] = *d
+	}
+
+	m := webos.NewManager(devices)
+	m.KeepAlive = time.Duration(c.Timeouts.KeepAlive)
+	m.Dial = func(_ string, d webos.DeviceConfig) (*webos.TV, error) {
+		return c.dial(&d)
+	}
+	return m
+}
+
+// orDefault returns d, or def if d is zero.
+func orDefault(d Duration, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDialDeviceWithoutFingerprint(t *testing.T) {
+	// nothing listens on either port, so the TV refuses the wss connection
+	_, err := New().DialDevice(&Device{Address: "127.0.0.1"})
+	if err == nil {
+		t.Skip("a server is listening on port 3001")
+	}
+
+	if !strings.Contains(err.Error(), "TLS mode none") || strings.Contains(err.Error(), "ws://") {
+		t.Errorf("DialDevice returned %v, want the wss connection refused without falling back to ws", err)
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	c := New()
+	c.Manifest = "basic"
+	c.Devices["tv"] = &Device{Address: "192.168.1.67", Manifest: "full"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if m := c.manifest(c.Devices["tv"]); m != "full" {
+		t.Errorf("the device's manifest is %s, want its own, full", m)
+	}
+
+	c.Devices["tv"].Manifest = "unknown"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "unknown manifest preset") {
+		t.Errorf("Validate returned %v, want the unknown manifest preset", err)
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeviceFlags are the repeated -tv flags of the commands, in the format
+// name=address,client-key, which add devices to the configuration file.
+//
+//	tvs := config.DeviceFlags{}
+//	flag.Var(tvs, "tv", "TV in the format name=address,client-key, may be repeated")
+type DeviceFlags map[string]*Device
+
+// String implements flag.Value.
+func (f DeviceFlags) String() string {
+	names := make([]string, 0, len(f))
+	for name, d := range f {
+		names = append(names, name+"="+d.Address)
+	}
+	return strings.Join(names, " ")
+}
+
+// Set implements flag.Value.
+func (f DeviceFlags) Set(v string) error {
+	name, rest, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=address,client-key: %s", v)
+	}
+
+	address, key, _ := strings.Cut(rest, ",")
+	f[name] = &Device{Address: address, ClientKey: key}
+	return nil
+}
+
+// LoadWithFlags loads the configuration file, if path isn't empty, adds the devices
+// given by the flags, replacing devices of the same name, and validates the result.
+func LoadWithFlags(path string, devices DeviceFlags) (*Config, error) {
+	cfg := New()
+	if path != "" {
+		var err error
+		if cfg, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, d := range devices {
+		cfg.Devices[name] = d
+	}
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tvs.yaml")
+	err := os.WriteFile(path, []byte(`
+devices:
+  living-room:
+    address: 192.168.1.67
+    client_key: old
+  bedroom:
+    address: 192.168.1.68
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tvs := DeviceFlags{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(tvs, "tv", "")
+	if err := fs.Parse([]string{"-tv", "living-room=192.168.1.70,new", "-tv", "kitchen=192.168.1.69"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadWithFlags(path, tvs)
+	if err != nil {
+		t.Fatalf("LoadWithFlags: %v", err)
+	}
+
+	want := map[string]Device{
+		"living-room": {Address: "192.168.1.70", ClientKey: "new"},
+		"bedroom":     {Address: "192.168.1.68"},
+		"kitchen":     {Address: "192.168.1.69"},
+	}
+	if len(cfg.Devices) != len(want) {
+		t.Fatalf("devices are %v, want %v", cfg.Names(), want)
+	}
+	for name, w := range want {
+		d := cfg.Devices[name]
+		if d == nil || d.Address != w.Address || d.ClientKey != w.ClientKey {
+			t.Errorf("device %s is %+v, want %+v", name, d, w)
+		}
+	}
+}
+
+func TestDeviceFlagsInvalid(t *testing.T) {
+	for _, v := range []string{"192.168.1.67", "=192.168.1.67"} {
+		if err := (DeviceFlags{}).Set(v); err == nil {
+			t.Errorf("Set(%q) succeeded", v)
+		}
+	}
+
+	if _, err := LoadWithFlags("", DeviceFlags{"tv": {}}); err == nil {
+		t.Error("LoadWithFlags succeeded with a device without an address")
+	}
+}
@@ -0,0 +1,330 @@
+package webos
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// field maps a value in a response Payload to a field of the decoded type.
+type field struct {
+	// path is the dot separated path of the value in the Payload, e.g. `volumeStatus.volume`.
+	path string
+
+	// name is the destination field name. Values with an empty name are known, but not decoded.
+	name string
+
+	// optional fields are not reported as missing in strict mode.
+	optional bool
+}
+
+// schema is a known layout of a response Payload.
+type schema struct {
+	// version is the first webOS major version known to use the layout.
+	version int
+	fields  []field
+}
+
+// DecodeError is returned when decoding in strict mode and a response Payload
+// does not exactly match any of the known schemas for the response type.
+type DecodeError struct {
+	Type    string
+	Version int
+	Missing []string
+	Unknown []string
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing fields: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown fields: "+strings.Join(e.Unknown, ", "))
+	}
+	return "could not strictly decode " + e.Type + ": " + strings.Join(parts, "; ")
+}
+
+// appSchemas are the known layouts of the getForegroundAppInfo response.
+var appSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue", name: "ReturnValue"},
+			{path: "appId", name: "AppID"},
+			{path: "windowId", name: "WindowID", optional: true},
+			{path: "processId", name: "ProcessID", optional: true},
+			{path: "subscribed", optional: true},
+		},
+	},
+}
+
+// appListSchemas are the known layouts of the listApps response.
+var appListSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue"},
+			{path: "apps", name: "Apps"},
+			{path: "subscribed", optional: true},
+		},
+	},
+}
+
+// externalInputListSchemas are the known layouts of the getExternalInputList response.
+var externalInputListSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue"},
+			{path: "devices", name: "Devices"},
+			{path: "subscribed", optional: true},
+		},
+	},
+}
+
+// appStateSchemas are the known layouts of the getAppState response.
+var appStateSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue", name: "ReturnValue"},
+			{path: "running", name: "Running"},
+			{path: "visible", name: "Visible"},
+			{path: "subscribed", optional: true},
+		},
+	},
+}
+
+// powerStateSchemas are the known layouts of the getPowerState response.
+var powerStateSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue"},
+			{path: "state", name: "State"},
+			{path: "processing", name: "Processing", optional: true},
+			{path: "powerOnReason", optional: true},
+			{path: "onOff", optional: true},
+			{path: "reason", optional: true},
+			{path: "subscribed", optional: true},
+		},
+	},
+}
+
+// serviceListSchemas are the known layouts of the getServiceList response.
+var serviceListSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue"},
+			{path: "services", name: "Services"},
+		},
+	},
+}
+
+// systemInfoSchemas are the known layouts of the getSystemInfo response.
+var systemInfoSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue"},
+			{path: "modelName", name: "ModelName"},
+			{path: "receiverType", name: "ReceiverType", optional: true},
+			{path: "features", name: "Features", optional: true},
+			{path: "programMode", optional: true},
+		},
+	},
+}
+
+// softwareInfoSchemas are the known layouts of the getCurrentSWInformation response.
+var softwareInfoSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue"},
+			{path: "product_name", name: "ProductName"},
+			{path: "model_name", name: "ModelName"},
+			{path: "major_ver", name: "MajorVersion"},
+			{path: "minor_ver", name: "MinorVersion"},
+			{path: "country", name: "Country", optional: true},
+			{path: "device_id", optional: true},
+			{path: "sw_type", optional: true},
+			{path: "auth_flag", optional: true},
+			{path: "ignore_disable", optional: true},
+			{path: "eco_info", optional: true},
+			{path: "config_key", optional: true},
+			{path: "language_code", optional: true},
+		},
+	},
+}
+
+// volumeSchemas are the known layouts of the getVolume response. webOS 5 moved
+// the volume information into the nested `volumeStatus` object.
+var volumeSchemas = []schema{
+	{
+		version: 1,
+		fields: []field{
+			{path: "returnValue", name: "ReturnValue"},
+			{path: "scenario", name: "Scenario"},
+			{path: "volume", name: "Volume"},
+			{path: "muted", name: "Muted"},
+			{path: "volumeMax", name: "MaxVolume", optional: true},
+			{path: "action", optional: true},
+			{path: "active", optional: true},
+			{path: "cause", optional: true},
+			{path: "subscribed", optional: true},
+		},
+	},
+	{
+		version: 5,
+		fields: []field{
+			{path: "returnValue", name: "ReturnValue"},
+			{path: "volumeStatus.volume", name: "Volume"},
+			{path: "volumeStatus.muteStatus", name: "Muted"},
+			{path: "volumeStatus.maxVolume", name: "MaxVolume", optional: true},
+			{path: "volumeStatus.soundOutput", name: "SoundOutput", optional: true},
+			{path: "volumeStatus.mode", optional: true},
+			{path: "volumeStatus.activeStatus", optional: true},
+			{path: "volumeStatus.adjustVolume", optional: true},
+			{path: "volumeStatus.cause", optional: true},
+			{path: "volumeStatus.externalDeviceControl", optional: true},
+			{path: "volumeStatus.volumeLimitable", optional: true},
+			{path: "volumeStatus.volumeSettingRange", optional: true},
+			{path: "callerId", optional: true},
+			{path: "subscribed", optional: true},
+		},
+	},
+}
+
+// responseDecoders are the response types and schemas of Commands, used to decode
+// Subscription responses.
+var responseDecoders = map[Command]struct {
+	typ     string
+	schemas []schema
+}{
+	ApplicationManagerForegroundAppCommand: {"App", appSchemas},
+	ApplicationManagerListAppsCommand:      {"AppList", appListSchemas},
+	AudioGetVolumeCommand:                  {"Volume", volumeSchemas},
+	PowerStateCommand:                      {"PowerState", powerStateSchemas},
+	SystemLauncherGetAppStateCommand:       {"App", appStateSchemas},
+	TVExternalInputListCommand:             {"ExternalInputList", externalInputListSchemas},
+}
+
+// SetStrictDecoding enables or disables strict decoding of responses. In strict mode a
+// response which doesn't exactly match a known schema returns a *DecodeError.
+func (tv *TV) SetStrictDecoding(strict bool) {
+	tv.strict.Store(strict)
+}
+
+// decode decodes the Payload p into v using the best matching of the given schemas.
+func (tv *TV) decode(typ string, p Payload, v interface{}, schemas []schema) error {
+	values := make(map[string]interface{})
+	flatten("", p, values)
+
+	s := bestSchema(values, schemas, tv.Capabilities().WebOSVersion())
+
+	out := make(map[string]interface{})
+	known := make(map[string]bool)
+	var missing []string
+	for _, f := range s.fields {
+		known[f.path] = true
+
+		value, ok := values[f.path]
+		if !ok {
+			if !f.optional {
+				missing = append(missing, f.path)
+			}
+			continue
+		}
+
+		if f.name != "" {
+			out[f.name] = value
+		}
+	}
+
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           v,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := dec.Decode(out); err != nil {
+		return errors.Wrapf(err, "could not decode %s", typ)
+	}
+
+	if !tv.strict.Load() {
+		return nil
+	}
+
+	var unknown []string
+	for path, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			continue
+		}
+
+		if !known[path] && !knownParent(path, known) {
+			unknown = append(unknown, path)
+		}
+	}
+
+	if len(missing) == 0 && len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return &DecodeError{Type: typ, Version: s.version, Missing: missing, Unknown: unknown}
+}
+
+// bestSchema returns the schema which matches the most values. When schemas match
+// equally well, the newest schema supported by the given webOS version is preferred.
+func bestSchema(values map[string]interface{}, schemas []schema, version int) schema {
+	best, score := schemas[0], -1
+	for _, s := range schemas {
+		n := 0
+		for _, f := range s.fields {
+			if _, ok := values[f.path]; ok {
+				n++
+			}
+		}
+
+		if n > score || (n == score && version >= s.version) {
+			best, score = s, n
+		}
+	}
+	return best
+}
+
+// flatten adds the values of m to values keyed by their dot separated path.
+// Nested objects are added both as a whole and flattened, arrays are kept as a
+// single value.
+func flatten(prefix string, m map[string]interface{}, values map[string]interface{}) {
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		values[path] = v
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(path, nested, values)
+		}
+	}
+}
+
+// knownParent returns true if a parent of path is a known value, e.g. a
+// nested object decoded as a whole.
+func knownParent(path string, known map[string]bool) bool {
+	for i := strings.LastIndex(path, "."); i > 0; i = strings.LastIndex(path[:i], ".") {
+		if known[path[:i]] {
+			return true
+		}
+	}
+	return false
+}
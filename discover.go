@@ -0,0 +1,87 @@
+package webos
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ssdpAddr is the SSDP multicast address.
+const ssdpAddr = "239.255.255.250:1900"
+
+// ssdpSearch is the SSDP M-SEARCH request for webOS TVs.
+const ssdpSearch = "M-SEARCH * HTTP/1.1\r\n" +
+	"HOST: 239.255.255.250:1900\r\n" +
+	"MAN: \"ssdp:discover\"\r\n" +
+	"MX: 2\r\n" +
+	"ST: urn:lge-com:service:webos-second-screen:1\r\n\r\n"
+
+// Device is a TV found by Discover.
+type Device struct {
+	// Address is the IP address of the TV, which can be passed to NewTV.
+	Address string
+
+	// Name is the friendly name of the TV, if advertised.
+	Name string
+
+	// Server is the SSDP server string, which contains the webOS version.
+	Server string
+}
+
+// Discover searches the local network for webOS TVs using SSDP, waiting for
+// responses until the timeout.
+func Discover(timeout time.Duration) ([]Device, error) {
+	addr, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteTo([]byte(ssdpSearch), addr); err != nil {
+		return nil, err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	seen := make(map[string]bool)
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return devices, nil
+			}
+			return devices, err
+		}
+
+		res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		res.Body.Close()
+
+		host, _, err := net.SplitHostPort(from.String())
+		if err != nil || seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		name, _ := url.QueryUnescape(res.Header.Get("DLNADeviceName.lge.com"))
+		devices = append(devices, Device{
+			Address: host,
+			Name:    name,
+			Server:  res.Header.Get("Server"),
+		})
+	}
+}
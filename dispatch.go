@@ -0,0 +1,189 @@
+package webos
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// responseBuffer is the buffer of the channels receiving responses, as registration
+// receives more than one response.
+const responseBuffer = 4
+
+// maxPooledBuffer is the capacity above which encoding buffers aren't reused, so one
+// large Message doesn't hold on to memory.
+const maxPooledBuffer = 64 << 10
+
+var (
+	// responseChannels are the channels of finished requests, reused by later requests.
+	responseChannels = sync.Pool{
+		New: func() interface{} { return make(chan Message, responseBuffer) },
+	}
+
+	// encodeBuffers are the buffers Messages are encoded into.
+	encodeBuffers = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+
+	// timers are the stopped timers of finished requests.
+	timers sync.Pool
+)
+
+// getTimer returns a timer which fires after d.
+func getTimer(d time.Duration) *time.Timer {
+	if t, ok := timers.Get().(*time.Timer); ok {
+		t.Reset(d)
+		return t
+	}
+	return time.NewTimer(d)
+}
+
+// putTimer stops the timer and returns it to be reused.
+func putTimer(t *time.Timer) {
+	if !t.Stop() {
+		// the timer fired, but its value may not have been received
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	timers.Put(t)
+}
+
+// idGenerator generates the Message IDs of a connection: a random prefix, unique to
+// the connection, followed by a counter. IDs are unique across every request and
+// Subscription of the connection, and don't repeat those of other connections.
+type idGenerator struct {
+	once   sync.Once
+	prefix string
+	n      atomic.Uint64
+}
+
+// next returns the next ID, e.g. `3f9a0c1e-17`.
+func (g *idGenerator) next() string {
+	g.once.Do(func() {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			// the counter keeps IDs unique within the connection
+			g.prefix = strconv.FormatInt(time.Now().UnixNano(), 36)
+			return
+		}
+		g.prefix = hex.EncodeToString(b)
+	})
+
+	return g.prefix + "-" + strconv.FormatUint(g.n.Add(1), 10)
+}
+
+// responseChannel returns the channel receiving the responses to the Message ID, or
+// nil if there isn't one.
+func (tv *TV) responseChannel(id string) chan Message {
+	tv.resMutex.Lock()
+	defer tv.resMutex.Unlock()
+
+	if p, ok := tv.res[id]; ok {
+		return p.ch
+	}
+	return nil
+}
+
+// pendingResponse is a request or Subscription waiting for responses from the TV.
+type pendingResponse struct {
+	ch chan Message
+
+	// sent is when the request was sent, recorded when logging to log the latency
+	// of its responses.
+	sent time.Time
+
+	// pooled channels are returned to responseChannels when the request is finished,
+	// rather than closed.
+	pooled bool
+}
+
+// release returns the channel of a finished request to responseChannels, or closes it
+// if it isn't pooled. No more responses can be sent to the channel.
+func (p *pendingResponse) release() {
+	if !p.pooled {
+		close(p.ch)
+		return
+	}
+
+	// responses which arrived after the request finished are discarded
+	for {
+		select {
+		case <-p.ch:
+		default:
+			responseChannels.Put(p.ch)
+			return
+		}
+	}
+}
+
+// encodeMessage encodes the Message as JSON into buf. The type, ID and URI are written
+// directly, so encoding/json is only used for the Payload, and not at all for the
+// Commands which don't take one.
+func encodeMessage(buf *bytes.Buffer, msg *Message) error {
+	buf.WriteByte('{')
+
+	first := true
+	writeField(buf, &first, "type", string(msg.Type))
+	writeField(buf, &first, "id", msg.ID)
+	writeField(buf, &first, "uri", string(msg.URI))
+
+	if len(msg.Payload) > 0 {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		buf.WriteString(`"payload":`)
+		enc := json.NewEncoder(buf)
+		if err := enc.Encode(msg.Payload); err != nil {
+			return err
+		}
+		// Encode terminates the value with a newline
+		buf.Truncate(buf.Len() - 1)
+	}
+
+	writeField(buf, &first, "error", msg.Error)
+
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeField writes the string field, unless it's empty.
+func writeField(buf *bytes.Buffer, first *bool, name, value string) {
+	if value == "" {
+		return
+	}
+
+	if !*first {
+		buf.WriteByte(',')
+	}
+	*first = false
+
+	buf.WriteByte('"')
+	buf.WriteString(name)
+	buf.WriteString(`":`)
+	writeString(buf, value)
+}
+
+// writeString writes the JSON string. Strings of printable ASCII characters which
+// don't need escaping, such as IDs and URIs, are written as they are.
+func writeString(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c > 0x7e || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			b, _ := json.Marshal(s)
+			buf.Write(b)
+			return
+		}
+	}
+
+	buf.WriteByte('"')
+	buf.WriteString(s)
+	buf.WriteByte('"')
+}
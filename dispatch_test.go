@@ -0,0 +1,53 @@
+package webos
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIDsDontCollide(t *testing.T) {
+	var a, b idGenerator
+	if a.next() == b.next() {
+		t.Fatal("two connections generated the same first ID")
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for _, g := range []*idGenerator{&a, &a, &b, &b} {
+		wg.Add(1)
+		go func(g *idGenerator) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				id := g.next()
+
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("ID %s was generated twice", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestDuplicatePendingID(t *testing.T) {
+	tv := &TV{}
+	if _, err := tv.setupResponseChannel("1", false); err != nil {
+		t.Fatalf("setupResponseChannel: %v", err)
+	}
+
+	_, err := tv.setupResponseChannel("1", false)
+	if err == nil || !strings.Contains(err.Error(), "duplicate message ID") {
+		t.Errorf("setupResponseChannel returned %v for a pending ID, want a duplicate message ID", err)
+	}
+
+	// the ID can be reused once its response is received
+	tv.teardownResponseChannel("1")
+	if _, err := tv.setupResponseChannel("1", false); err != nil {
+		t.Errorf("setupResponseChannel returned %v once the ID was no longer pending", err)
+	}
+}
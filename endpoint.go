@@ -0,0 +1,117 @@
+package webos
+
+import (
+	"context"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Empty is the request or response type of Endpoints which don't take a request or
+// return a response.
+type Empty struct{}
+
+// Endpoint is a typed SSAP endpoint: the Command, the type of its request Payload and
+// the type its response Payload is decoded into. New endpoints are defined in one
+// line, including for Commands the package doesn't cover:
+//
+//	var listLaunchPoints = webos.NewEndpoint[webos.Empty, LaunchPoints]("ssap://com.webos.applicationManager/listLaunchPoints")
+//
+//	lp, err := listLaunchPoints.Call(ctx, tv, webos.Empty{})
+//
+// Requests are encoded, and responses decoded, by field name or `mapstructure` tag:
+//
+//	type LaunchPoints struct {
+//		LaunchPoints []struct {
+//			ID    string `mapstructure:"id"`
+//			Title string `mapstructure:"title"`
+//		} `mapstructure:"launchPoints"`
+//	}
+type Endpoint[Req, Resp any] struct {
+	// URI is the Command called.
+	URI Command
+
+	// typ and schemas decode the response tolerating the differences between webOS
+	// versions, if the layouts of the response are known.
+	typ     string
+	schemas []schema
+}
+
+// NewEndpoint returns the Endpoint for the Command.
+func NewEndpoint[Req, Resp any](uri Command) Endpoint[Req, Resp] {
+	return Endpoint[Req, Resp]{URI: uri}
+}
+
+// Call calls the Endpoint on the TV with the request and returns the decoded response.
+// A response which can't be decoded is returned along with the error, e.g. a
+// *DecodeError in strict mode.
+func (e Endpoint[Req, Resp]) Call(ctx context.Context, tv *TV, req Req) (Resp, error) {
+	var resp Resp
+
+	msg, err := e.request(ctx, tv, req)
+	if err != nil {
+		return resp, err
+	}
+	return resp, e.decode(tv, msg.Payload, &resp)
+}
+
+// request makes the request.
+func (e Endpoint[Req, Resp]) request(ctx context.Context, tv *TV, req Req) (Message, error) {
+	p, err := encodeRequest(req)
+	if err != nil {
+		return Message{}, errors.Wrapf(err, "could not encode %s request", e.URI)
+	}
+	return tv.CommandContext(ctx, e.URI, p)
+}
+
+// decode decodes the response Payload into v.
+func (e Endpoint[Req, Resp]) decode(tv *TV, p Payload, v *Resp) error {
+	switch {
+	case isEmpty(v):
+		return nil
+	case e.schemas != nil:
+		return tv.decode(e.typ, p, v, e.schemas)
+	default:
+		return mapstructure.WeakDecode(p, v)
+	}
+}
+
+// Call calls the Command on the TV with the request and returns the decoded response,
+// as NewEndpoint[Req, Resp](uri).Call(ctx, tv, req).
+func Call[Req, Resp any](ctx context.Context, tv *TV, uri Command, req Req) (Resp, error) {
+	return NewEndpoint[Req, Resp](uri).Call(ctx, tv, req)
+}
+
+// encodeRequest returns the Payload of the request. Empty requests have no Payload.
+func encodeRequest(req interface{}) (Payload, error) {
+	switch r := req.(type) {
+	case Empty, *Empty, nil:
+		return nil, nil
+	case Payload:
+		return r, nil
+	}
+
+	p := Payload{}
+	if err := mapstructure.Decode(req, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// isEmpty returns true if v is a pointer to Empty.
+func isEmpty(v interface{}) bool {
+	_, ok := v.(*Empty)
+	return ok
+}
+
+// call calls the Endpoint without a context, returning a pointer to the response, or
+// nil if the request fails.
+func call[Req, Resp any](tv *TV, e Endpoint[Req, Resp], req Req) (*Resp, error) {
+	msg, err := e.request(context.Background(), tv, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(Resp)
+	return resp, e.decode(tv, msg.Payload, resp)
+}
@@ -0,0 +1,124 @@
+package webos_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// launchRequest and launchResponse are the request and response of launchEndpoint.
+type launchRequest struct {
+	ID     string         `mapstructure:"id"`
+	Params map[string]int `mapstructure:"params"`
+}
+
+type launchResponse struct {
+	SessionID string `mapstructure:"sessionId"`
+	Count     int    `mapstructure:"count"`
+}
+
+const launchURI webos.Command = "ssap://com.example.service/launch"
+
+var launchEndpoint = webos.NewEndpoint[launchRequest, launchResponse](launchURI)
+
+// payload returns the Payload of the last request the Server received for uri.
+func payload(t *testing.T, srv *webostest.Server, uri webos.Command) webos.Payload {
+	t.Helper()
+
+	msgs := srv.Messages()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].URI == uri {
+			return msgs[i].Payload
+		}
+	}
+	t.Fatalf("no request was received for %s", uri)
+	return nil
+}
+
+func TestEndpointCall(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(launchURI, webos.Payload{"sessionId": "abc", "count": "3"})
+
+	res, err := launchEndpoint.Call(context.Background(), connect(t, srv), launchRequest{ID: "netflix", Params: map[string]int{"x": 1}})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	// the count is decoded from a string, as some webOS versions return numbers as strings
+	if want := (launchResponse{SessionID: "abc", Count: 3}); res != want {
+		t.Errorf("response is %+v, want %+v", res, want)
+	}
+
+	want := webos.Payload{"id": "netflix", "params": map[string]interface{}{"x": float64(1)}}
+	if p := payload(t, srv, launchURI); !reflect.DeepEqual(p, want) {
+		t.Errorf("request Payload is %v, want %v", p, want)
+	}
+}
+
+func TestCallPayloads(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(launchURI, webos.Payload{"sessionId": "abc"})
+
+	tv := connect(t, srv)
+	ctx := context.Background()
+
+	if _, err := webos.Call[webos.Empty, webos.Empty](ctx, tv, launchURI, webos.Empty{}); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if p := payload(t, srv, launchURI); p != nil {
+		t.Errorf("Empty request has the Payload %v, want none", p)
+	}
+
+	res, err := webos.Call[webos.Payload, webos.Payload](ctx, tv, launchURI, webos.Payload{"id": "netflix"})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if res["sessionId"] != "abc" {
+		t.Errorf("Payload response is %v, want the sessionId", res)
+	}
+	if p := payload(t, srv, launchURI); p["id"] != "netflix" {
+		t.Errorf("request Payload is %v, want it sent as given", p)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondError(launchURI, 401, "insufficient permissions")
+	srv.Respond("ssap://com.example.service/fail", webos.Payload{"returnValue": false, "errorCode": -1000, "errorText": "app not found"})
+
+	tv := connect(t, srv)
+	ctx := context.Background()
+
+	if _, err := launchEndpoint.Call(ctx, tv, launchRequest{}); !errors.Is(err, webos.ErrPermissionDenied) {
+		t.Errorf("Call returned %v, want ErrPermissionDenied", err)
+	}
+
+	_, err := webos.Call[webos.Empty, launchResponse](ctx, tv, "ssap://com.example.service/fail", webos.Empty{})
+	var apiErr *webos.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != -1000 || apiErr.Text != "app not found" {
+		t.Errorf("Call returned %v, want the APIError of the false returnValue", err)
+	}
+
+	_, err = webos.Call[webos.Empty, webos.Empty](ctx, tv, "ssap://com.example.service/unknown", webos.Empty{})
+	if !errors.As(err, &apiErr) || apiErr.Code != 404 {
+		t.Errorf("Call returned %v, want a 404 APIError", err)
+	}
+
+	// requests which can't be encoded aren't sent
+	n := len(srv.Messages())
+	_, err = webos.Call[int, webos.Empty](ctx, tv, launchURI, 1)
+	if err == nil || !strings.Contains(err.Error(), "could not encode") {
+		t.Errorf("Call returned %v for a request which isn't a struct, want an encoding error", err)
+	}
+	if len(srv.Messages()) != n {
+		t.Error("a request which couldn't be encoded was sent")
+	}
+}
@@ -0,0 +1,127 @@
+package webos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrTimeout is returned when the TV doesn't respond to a request in time.
+	ErrTimeout = errors.New("timeout")
+
+	// ErrConnectionClosed is returned when the connection to the TV is closed before
+	// a response is received.
+	ErrConnectionClosed = errors.New("connection closed")
+
+	// ErrNotRegistered is returned by the TV when a Command is sent before authorising.
+	ErrNotRegistered = errors.New("not registered")
+
+	// ErrPermissionDenied is returned by the TV when the client isn't permitted to
+	// execute a Command.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrCertificateChanged is returned when the TV's certificate doesn't have the
+	// fingerprint pinned by WithCertificatePin, e.g. as another device is
+	// impersonating the TV.
+	ErrCertificateChanged = errors.New("certificate changed")
+
+	// ErrSuperseded is returned by RateLimitMiddleware when a queued request is
+	// dropped in favour of a newer request for the same Command.
+	ErrSuperseded = errors.New("superseded by a newer request")
+
+	// ErrUnknownButton is returned by PressButton for names which aren't Buttons.
+	ErrUnknownButton = errors.New("unknown button")
+)
+
+// APIError is an error returned by the TV, either as an `error` Message or as a
+// response Payload with a false `returnValue`.
+type APIError struct {
+	// Code is the SSAP error code, e.g. 401 or -1000. It is 0 if the TV didn't
+	// return a code.
+	Code int
+
+	// Text is the error text returned by the TV.
+	Text string
+
+	// URI is the Command which caused the error.
+	URI Command
+
+	// ID is the ID of the Message which caused the error.
+	ID string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	b.WriteString("API error")
+	if e.URI != "" {
+		b.WriteString(" (" + string(e.URI) + ")")
+	}
+	if e.Code != 0 {
+		b.WriteString(": " + strconv.Itoa(e.Code))
+	}
+	if e.Text != "" {
+		b.WriteString(": " + e.Text)
+	}
+	return b.String()
+}
+
+// Is reports whether the APIError matches one of the sentinel errors, so APIErrors
+// can be tested using errors.Is. The TV returns 401 for several errors, so they are
+// told apart by their text, e.g. "insufficient permissions" or "insufficient
+// permissions (not registered)".
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrPermissionDenied:
+		return e.Code == 401 && strings.Contains(strings.ToLower(e.Text), "permission") && !e.notRegistered()
+	case ErrNotRegistered:
+		return e.notRegistered()
+	default:
+		return false
+	}
+}
+
+// notRegistered returns true if the TV rejected the request as the client hasn't
+// registered, or registered without a client key.
+func (e *APIError) notRegistered() bool {
+	text := strings.ToLower(e.Text)
+	return e.Code == 401 && (strings.Contains(text, "not registered") || strings.Contains(text, "no client key"))
+}
+
+// parseAPIError parses errors in the format "401 insufficient permissions" returned
+// in the `error` field of a Message.
+func parseAPIError(s string) *APIError {
+	parts := strings.SplitN(s, " ", 2)
+	code, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) == 1 {
+		return &APIError{Text: s}
+	}
+	return &APIError{Code: code, Text: parts[1]}
+}
+
+// errorCode converts the `errorCode` returned in a Payload, which depending on the
+// TV firmware is either a number or a string.
+func errorCode(v interface{}) int {
+	switch c := v.(type) {
+	case float64:
+		return int(c)
+	case int:
+		return c
+	case string:
+		code, _ := strconv.Atoi(c)
+		return code
+	default:
+		return 0
+	}
+}
+
+// errorText converts the `errorText` returned in a Payload.
+func errorText(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
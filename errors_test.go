@@ -0,0 +1,48 @@
+package webos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		err           string
+		denied        bool
+		notRegistered bool
+	}{
+		{err: "401 insufficient permissions", denied: true},
+		{err: "401 Insufficient Permissions", denied: true},
+		{err: "401 insufficient permissions (not registered)", notRegistered: true},
+		{err: "401 no client key", notRegistered: true},
+		{err: "401 invalid signature"},
+		{err: "401"},
+		{err: "403 insufficient permissions"},
+		{err: "404 no such service or method"},
+	}
+
+	for _, tt := range tests {
+		err := error(parseAPIError(tt.err))
+		if got := errors.Is(err, ErrPermissionDenied); got != tt.denied {
+			t.Errorf("%q: Is(ErrPermissionDenied) is %t, want %t", tt.err, got, tt.denied)
+		}
+		if got := errors.Is(err, ErrNotRegistered); got != tt.notRegistered {
+			t.Errorf("%q: Is(ErrNotRegistered) is %t, want %t", tt.err, got, tt.notRegistered)
+		}
+	}
+}
+
+func TestPayloadValidate(t *testing.T) {
+	err := Payload{"returnValue": false, "errorCode": "401", "errorText": "insufficient permissions"}.Validate()
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Validate returned %v, want an *APIError", err)
+	}
+	if apiErr.Code != 401 {
+		t.Errorf("Code is %d, want 401", apiErr.Code)
+	}
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("%v isn't ErrPermissionDenied", err)
+	}
+}
@@ -0,0 +1,181 @@
+package webos
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EventType is the type of TV state change reported by a Watcher.
+type EventType string
+
+const (
+	// VolumeEvent reports volume and mute changes. Data is a *Volume.
+	VolumeEvent EventType = "volume"
+
+	// AppEvent reports foreground app changes. Data is an *App.
+	AppEvent EventType = "app"
+
+	// PowerEvent reports power state changes, e.g. the screen turning off. Data is a *PowerState.
+	PowerEvent EventType = "power"
+
+	// ChannelEvent reports channel changes. Data is the Payload.
+	ChannelEvent EventType = "channel"
+
+	// MediaEvent reports media play state changes. Data is the Payload.
+	MediaEvent EventType = "media"
+)
+
+// EventTypes are all the EventTypes, in the order they are subscribed to by Watch.
+var EventTypes = []EventType{VolumeEvent, AppEvent, PowerEvent, ChannelEvent, MediaEvent}
+
+// eventCommands are the Commands subscribed to for each EventType.
+var eventCommands = map[EventType]Command{
+	VolumeEvent:  AudioGetVolumeCommand,
+	AppEvent:     ApplicationManagerForegroundAppCommand,
+	PowerEvent:   PowerStateCommand,
+	ChannelEvent: TVCurrentChannelCommand,
+	MediaEvent:   MediaForegroundAppCommand,
+}
+
+// Event is a TV state change.
+type Event struct {
+	Type EventType   `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Watcher multiplexes Subscriptions to TV state into Events.
+type Watcher struct {
+	// C receives the current state of each watched EventType followed by every change.
+	// It is closed when the Watcher or the connection is closed.
+	C <-chan Event
+
+	// Errors are the errors subscribing to EventTypes which aren't watched, e.g.
+	// because the TV doesn't support them.
+	Errors map[EventType]error
+
+	subs []*Subscription
+	once sync.Once
+}
+
+// eventBuffer is the number of Events buffered by a Watcher, in addition to the
+// initial state of each EventType.
+const eventBuffer = 16
+
+// Watch subscribes to the given EventTypes, or all EventTypes if none are given.
+// EventTypes which can't be subscribed to are reported in Watcher.Errors; an error
+// is returned only if none can be subscribed to.
+//
+// The initial state of each EventType is buffered in C before Watch returns, so it's
+// never dropped. Changes are dropped if C isn't read and its buffer is full.
+func (tv *TV) Watch(types ...EventType) (*Watcher, error) {
+	if len(types) == 0 {
+		types = EventTypes
+	}
+
+	w := &Watcher{Errors: make(map[EventType]error)}
+	subTypes := make([]EventType, 0, len(types))
+	for _, typ := range types {
+		uri, ok := eventCommands[typ]
+		if !ok {
+			w.Errors[typ] = errors.Errorf("unknown event type: %s", typ)
+			continue
+		}
+
+		sub, err := tv.Subscribe(uri, nil)
+		if err != nil {
+			w.Errors[typ] = err
+			continue
+		}
+		w.subs = append(w.subs, sub)
+		subTypes = append(subTypes, typ)
+	}
+
+	if len(w.subs) == 0 {
+		return nil, errors.New("could not subscribe to any event type")
+	}
+
+	out := make(chan Event, len(w.subs)+eventBuffer)
+	w.C = out
+
+	// the first response of each Subscription is the initial state, which is already
+	// received, so it's queued before any changes
+	for i, sub := range w.subs {
+		e, err := decodeEvent(subTypes[i], sub, <-sub.C)
+		if err != nil {
+			tv.log(slog.LevelWarn, "could not decode event", slog.String("type", string(subTypes[i])), slog.Any("error", err))
+			continue
+		}
+		out <- e
+	}
+
+	var wg sync.WaitGroup
+	for i, sub := range w.subs {
+		wg.Add(1)
+		go func(typ EventType, sub *Subscription) {
+			defer wg.Done()
+			for msg := range sub.C {
+				e, err := decodeEvent(typ, sub, msg)
+				if err != nil {
+					tv.log(slog.LevelWarn, "could not decode event", slog.String("type", string(typ)), slog.Any("error", err))
+					continue
+				}
+
+				select {
+				case out <- e:
+				default:
+					tv.log(slog.LevelWarn, "dropped event, the receiver isn't reading", slog.String("type", string(typ)))
+				}
+			}
+		}(subTypes[i], sub)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return w, nil
+}
+
+// Close closes the Subscriptions and C.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		for _, sub := range w.subs {
+			if e := sub.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+// decodeEvent decodes a Subscription response into an Event.
+func decodeEvent(typ EventType, sub *Subscription, msg Message) (Event, error) {
+	e := Event{Type: typ, Time: time.Now()}
+
+	var err error
+	switch typ {
+	case VolumeEvent:
+		v := &Volume{}
+		err = sub.Decode(msg, v)
+		e.Data = v
+	case AppEvent:
+		a := &App{}
+		err = sub.Decode(msg, a)
+		e.Data = a
+	case PowerEvent:
+		p := &PowerState{}
+		err = sub.Decode(msg, p)
+		e.Data = p
+	default:
+		err = msg.Validate()
+		e.Data = msg.Payload
+	}
+
+	return e, err
+}
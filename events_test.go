@@ -0,0 +1,74 @@
+package webos_test
+
+import (
+	"testing"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+func TestWatchInitialState(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	tv := connect(t, srv)
+	w, err := tv.Watch()
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Close()
+	if len(w.Errors) > 0 {
+		t.Fatalf("could not watch: %v", w.Errors)
+	}
+
+	// changes overflow the buffer while C isn't read
+	for i := 0; i < 64; i++ {
+		srv.Publish(webos.AudioGetVolumeCommand, webos.Payload{"volumeStatus": map[string]interface{}{"volume": i, "muteStatus": false}})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	for _, typ := range webos.EventTypes {
+		select {
+		case e := <-w.C:
+			if e.Type != typ {
+				t.Fatalf("got %s Event, want the initial %s Event", e.Type, typ)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no initial %s Event", typ)
+		}
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	tv := connect(t, srv)
+	w, err := tv.Watch(webos.PowerEvent)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	if e := <-w.C; e.Data.(*webos.PowerState).State != "Active" {
+		t.Fatalf("initial power state is %+v, want Active", e.Data)
+	}
+
+	srv.Publish(webos.PowerStateCommand, webos.Payload{"state": "Screen Off"})
+	select {
+	case e := <-w.C:
+		if e.Data.(*webos.PowerState).State != "Screen Off" {
+			t.Errorf("power state is %+v, want Screen Off", e.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no Event after the power state changed")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range w.C {
+	}
+}
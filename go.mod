@@ -1,7 +1,33 @@
 module github.com/kaperys/go-webos
 
+go 1.22
+
 require (
-	github.com/gorilla/websocket v1.2.0
+	github.com/eclipse/paho.mqtt.golang v1.2.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/mitchellh/mapstructure v0.0.0-20180715050151-f15292f7a699
-	github.com/pkg/errors v0.8.0
+	github.com/mochi-mqtt/server/v2 v2.7.9
+	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.19.1
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+	golang.org/x/term v0.27.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	github.com/rs/xid v1.4.0 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
 )
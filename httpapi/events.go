@@ -0,0 +1,308 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// hub watches the TV while there are event clients, fanning out Events to them and
+// keeping the latest Event of each type to replay to new clients.
+type hub struct {
+	tv func() (*webos.TV, error)
+
+	mu      sync.Mutex
+	watcher *webos.Watcher
+	latest  map[webos.EventType]webos.Event
+	clients map[*client]bool
+}
+
+// client is an events endpoint connection.
+type client struct {
+	ch    chan webos.Event
+	types map[webos.EventType]bool
+}
+
+// wants returns true if the client is interested in Events of the type.
+func (c *client) wants(typ webos.EventType) bool {
+	return len(c.types) == 0 || c.types[typ]
+}
+
+// newHub returns a hub for the TV returned by tv. The TV is watched once the first
+// client subscribes.
+func newHub(tv func() (*webos.TV, error)) *hub {
+	return &hub{
+		tv:      tv,
+		latest:  make(map[webos.EventType]webos.Event),
+		clients: make(map[*client]bool),
+	}
+}
+
+// subscribe adds a client for the types, or all types if none are given. The client
+// receives the latest Event of each type first.
+func (h *hub) subscribe(types []webos.EventType) (*client, error) {
+	h.mu.Lock()
+	watching := h.watcher != nil
+	h.mu.Unlock()
+
+	// the TV is watched without holding the lock, as subscribing waits for the TV
+	// and would hold up the Events of other clients
+	var w *webos.Watcher
+	if !watching {
+		tv, err := h.tv()
+		if err != nil {
+			return nil, err
+		}
+
+		if w, err = tv.Watch(); err != nil {
+			return nil, err
+		}
+	}
+
+	h.mu.Lock()
+	c, err := h.add(w, types)
+	h.mu.Unlock()
+	return c, err
+}
+
+// add adds a client for the types, starting to watch the TV with w if it isn't
+// already watched. w is closed if another client started watching first. h.mu must
+// be held.
+func (h *hub) add(w *webos.Watcher, types []webos.EventType) (*client, error) {
+	if w != nil {
+		if h.watcher == nil {
+			h.watcher = w
+			go h.run(w)
+		} else {
+			go w.Close()
+		}
+	}
+
+	// the connection was lost since the TV was watched
+	if h.watcher == nil {
+		return nil, fmt.Errorf("could not watch the TV: %w", webos.ErrConnectionClosed)
+	}
+
+	c := &client{ch: make(chan webos.Event, 16), types: make(map[webos.EventType]bool)}
+	for _, typ := range types {
+		c.types[typ] = true
+	}
+
+	for _, typ := range webos.EventTypes {
+		if e, ok := h.latest[typ]; ok && c.wants(typ) {
+			c.ch <- e
+		}
+	}
+
+	h.clients[c] = true
+	return c, nil
+}
+
+// unsubscribe removes the client. The TV is no longer watched once the last client
+// unsubscribes.
+func (h *hub) unsubscribe(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.clients[c] {
+		return
+	}
+
+	delete(h.clients, c)
+	close(c.ch)
+
+	if len(h.clients) == 0 && h.watcher != nil {
+		h.watcher.Close()
+		h.watcher = nil
+		h.latest = make(map[webos.EventType]webos.Event)
+	}
+}
+
+// run fans out the Watcher's Events until it is closed.
+func (h *hub) run(w *webos.Watcher) {
+	for e := range w.C {
+		h.mu.Lock()
+		h.latest[e.Type] = e
+		for c := range h.clients {
+			if !c.wants(e.Type) {
+				continue
+			}
+
+			// slow clients miss Events rather than blocking other clients
+			select {
+			case c.ch <- e:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// the Watcher was closed by the last client unsubscribing
+	if h.watcher != w {
+		return
+	}
+
+	// the connection is closed, clients are disconnected and the TV is watched
+	// again by the next client
+	h.watcher = nil
+	h.latest = make(map[webos.EventType]webos.Event)
+
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.ch)
+	}
+}
+
+// eventTypes parses the `types` query parameter, e.g. `?types=volume,app`.
+func eventTypes(r *http.Request) ([]webos.EventType, error) {
+	q := r.URL.Query().Get("types")
+	if q == "" {
+		return nil, nil
+	}
+
+	valid := make(map[webos.EventType]bool)
+	for _, typ := range webos.EventTypes {
+		valid[typ] = true
+	}
+
+	var types []webos.EventType
+	for _, t := range strings.Split(q, ",") {
+		typ := webos.EventType(normalise(t))
+		if !valid[typ] {
+			return nil, &badRequestError{msg: "unknown event type: " + t}
+		}
+		types = append(types, typ)
+	}
+	return types, nil
+}
+
+// serveEvents streams Events as Server-Sent Events.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	c, ok := s.subscribeEvents(w, r)
+	if !ok {
+		return
+	}
+	defer s.events.unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e, ok := <-c.ch:
+			if !ok {
+				return
+			}
+
+			b, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveEventsWebsocket streams Events as JSON websocket messages.
+func (s *Server) serveEventsWebsocket(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.subscribeEvents(w, r)
+	if !ok {
+		return
+	}
+	defer s.events.unsubscribe(c)
+
+	ws, err := (&websocket.Upgrader{CheckOrigin: s.checkOrigin}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	// read until the client closes the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case e, ok := <-c.ch:
+			if !ok {
+				return
+			}
+
+			if err := ws.WriteJSON(e); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// checkOrigin reports whether the websocket request is from the same host, has no
+// Origin header, as requests not made by browsers don't, or is from an allowed origin.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range s.origins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribeEvents subscribes to the Events requested, writing an error response if
+// the request is invalid or the TV can't be watched.
+func (s *Server) subscribeEvents(w http.ResponseWriter, r *http.Request) (*client, bool) {
+	types, err := eventTypes(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	c, err := s.events.subscribe(types)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return nil, false
+	}
+
+	return c, true
+}
@@ -0,0 +1,232 @@
+// Package httpapi exposes TVs over a JSON REST API, so services which aren't written
+// in Go can control them.
+//
+//	srv := httpapi.New(tv)
+//	log.Fatal(http.ListenAndServe(":8080", srv))
+//
+// The API is described by the OpenAPI document served at GET /openapi.json, which is
+// generated from the routes.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// Server serves the REST API for a single TV.
+type Server struct {
+	tv      func() (*webos.TV, error)
+	mux     *http.ServeMux
+	events  *hub
+	origins []string
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAllowedOrigins allows browsers on the origins, e.g. https://example.com, to open
+// the events websocket. By default only pages served from the same host may open it.
+// The origin "*" allows any origin.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(s *Server) {
+		s.origins = append(s.origins, origins...)
+	}
+}
+
+// New returns a Server for the TV. The TV must be connected, with the MessageHandler
+// running, and authorised.
+func New(tv *webos.TV, opts ...Option) *Server {
+	return newServer(func() (*webos.TV, error) { return tv, nil }, opts)
+}
+
+// NewManaged returns a Server for the named TV of the Manager. The TV is connected
+// when first used, and reconnected by the next request once the connection is lost,
+// e.g. as the TV was turned off.
+func NewManaged(m *webos.Manager, name string, opts ...Option) *Server {
+	return newServer(func() (*webos.TV, error) { return m.TV(name) }, opts)
+}
+
+// newServer returns a Server for the TV returned by tv.
+func newServer(tv func() (*webos.TV, error), opts []Option) *Server {
+	s := &Server{tv: tv, mux: http.NewServeMux(), events: newHub(tv)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, rt := range routes {
+		rt := rt
+		s.mux.HandleFunc(rt.method+" "+rt.path, func(w http.ResponseWriter, r *http.Request) {
+			if rt.stream != nil {
+				rt.stream(s, w, r)
+				return
+			}
+			s.serveRoute(rt, w, r)
+		})
+	}
+
+	s.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, OpenAPI())
+	})
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// NewMulti returns a handler serving the REST API for each named TV under
+// /tvs/{name}/, e.g. GET /tvs/living-room/volume. GET /tvs lists the names.
+func NewMulti(tvs map[string]*webos.TV, opts ...Option) http.Handler {
+	servers := make(map[string]*Server, len(tvs))
+	for name, tv := range tvs {
+		servers[name] = New(tv, opts...)
+	}
+	return multi(servers)
+}
+
+// NewManagerHandler returns a handler serving the REST API for each TV of the Manager
+// as NewMulti does, with each TV served as by NewManaged.
+func NewManagerHandler(m *webos.Manager, opts ...Option) http.Handler {
+	names := m.Names()
+	servers := make(map[string]*Server, len(names))
+	for _, name := range names {
+		servers[name] = NewManaged(m, name, opts...)
+	}
+	return multi(servers)
+}
+
+// multi returns a handler serving each named Server under /tvs/{name}/.
+func multi(servers map[string]*Server) http.Handler {
+	mux := http.NewServeMux()
+
+	names := make([]string, 0, len(servers))
+	for name, s := range servers {
+		names = append(names, name)
+
+		prefix := "/tvs/" + name
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, s))
+	}
+	sort.Strings(names)
+
+	mux.HandleFunc("GET /tvs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, names)
+	})
+
+	return mux
+}
+
+// serveRoute decodes the request body, calls the route handler and writes the response.
+func (s *Server) serveRoute(rt route, w http.ResponseWriter, r *http.Request) {
+	var body interface{}
+	if rt.request != nil {
+		body = newOf(rt.request)
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	tv, err := s.tv()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	res, err := rt.handle(tv, r, body)
+	if err != nil {
+		writeError(w, rt.status(err), err)
+		return
+	}
+
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// errorResponse is the body of error responses.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// badRequestError is returned by route handlers for invalid requests.
+type badRequestError struct {
+	msg string
+}
+
+// Error implements the error interface.
+func (e *badRequestError) Error() string {
+	return e.msg
+}
+
+// status returns the HTTP status code for an error returned by the route handler.
+func (rt route) status(err error) int {
+	var br *badRequestError
+	if errors.As(err, &br) {
+		return http.StatusBadRequest
+	}
+
+	switch {
+	case errors.Is(err, webos.ErrUnknownButton):
+		return http.StatusBadRequest
+	case errors.Is(err, webos.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, webos.ErrNotRegistered):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, webos.ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, webos.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, webos.ErrConnectionClosed):
+		return http.StatusBadGateway
+	}
+
+	var apiErr *webos.APIError
+	if errors.As(err, &apiErr) {
+		if status, ok := defaultCodes[apiErr.Code]; ok {
+			return status
+		}
+		if rt.apiStatus != 0 {
+			return rt.apiStatus
+		}
+		return http.StatusBadGateway
+	}
+
+	return http.StatusInternalServerError
+}
+
+// defaultCodes map SSAP error codes to HTTP status codes.
+var defaultCodes = map[int]int{
+	400: http.StatusBadRequest,
+	401: http.StatusForbidden,
+	404: http.StatusNotFound,
+	409: http.StatusConflict,
+	500: http.StatusBadGateway,
+}
+
+// normalise lower cases and trims s.
+func normalise(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
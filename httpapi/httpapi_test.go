@@ -0,0 +1,209 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/httpapi"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// newManager returns a Manager for the Server, as the TV named "tv".
+func newManager(t *testing.T, srv *webostest.Server) *webos.Manager {
+	t.Helper()
+
+	m := webos.NewManager(map[string]webos.DeviceConfig{
+		"tv": {Address: srv.Host(), ClientKey: webostest.DefaultClientKey},
+	})
+	m.Dialer = srv.Dialer()
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// get makes a GET request to the handler, decoding the JSON response body into v.
+func get(t *testing.T, h http.Handler, path string, v interface{}) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("could not decode %s response %q: %v", path, rec.Body, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestVolumeJSON(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 12, "muted": true})
+
+	var body map[string]interface{}
+	if code := get(t, httpapi.NewManaged(newManager(t, srv), "tv"), "/volume", &body); code != http.StatusOK {
+		t.Fatalf("GET /volume returned %d", code)
+	}
+
+	if body["volume"] != float64(12) || body["muted"] != true || body["scenario"] != "mastervolume_tv_speaker" {
+		t.Errorf("GET /volume returned %v, want lower camel case keys", body)
+	}
+}
+
+func TestManagedReconnects(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 12, "muted": false})
+
+	m := newManager(t, srv)
+	h := httpapi.NewManaged(m, "tv")
+	if code := get(t, h, "/volume", nil); code != http.StatusOK {
+		t.Fatalf("GET /volume returned %d", code)
+	}
+
+	// the connection is lost, e.g. as the TV was turned off
+	tv, err := m.TV("tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tv.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if code := get(t, h, "/volume", nil); code != http.StatusOK {
+		t.Fatalf("GET /volume returned %d after the connection was lost", code)
+	}
+	if reconnected, _ := m.TV("tv"); reconnected == tv {
+		t.Error("the TV wasn't reconnected")
+	}
+}
+
+func TestManagerHandler(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 3, "muted": false})
+
+	h := httpapi.NewManagerHandler(newManager(t, srv))
+
+	var names []string
+	if code := get(t, h, "/tvs", &names); code != http.StatusOK || len(names) != 1 || names[0] != "tv" {
+		t.Fatalf("GET /tvs returned %d %v, want [tv]", code, names)
+	}
+
+	var v webos.Volume
+	if code := get(t, h, "/tvs/tv/volume", &v); code != http.StatusOK || v.Volume != 3 {
+		t.Fatalf("GET /tvs/tv/volume returned %d %+v", code, v)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	srv := webostest.NewServer()
+	m := newManager(t, srv)
+	srv.Close()
+
+	if code := get(t, httpapi.NewManaged(m, "tv"), "/volume", nil); code != http.StatusBadGateway {
+		t.Fatalf("GET /volume returned %d for an unreachable TV, want %d", code, http.StatusBadGateway)
+	}
+}
+
+func TestEventsConcurrentClients(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	api := httptest.NewServer(httpapi.NewManaged(newManager(t, srv), "tv"))
+	defer api.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http")+"/events/ws?types=power", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer ws.Close()
+
+			ws.SetReadDeadline(time.Now().Add(time.Second))
+			var e struct {
+				Type string           `json:"type"`
+				Data webos.PowerState `json:"data"`
+			}
+			if err := ws.ReadJSON(&e); err != nil {
+				t.Error(err)
+				return
+			}
+			if e.Type != "power" || e.Data.State != "Active" {
+				t.Errorf("initial Event is %+v, want the Active power state", e)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEventsOrigin(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	m := newManager(t, srv)
+	for _, tt := range []struct {
+		opts   []httpapi.Option
+		origin string
+		ok     bool
+	}{
+		{nil, "", true},
+		{nil, "https://example.com", false},
+		{[]httpapi.Option{httpapi.WithAllowedOrigins("https://example.com")}, "https://example.com", true},
+		{[]httpapi.Option{httpapi.WithAllowedOrigins("https://example.com")}, "https://example.org", false},
+		{[]httpapi.Option{httpapi.WithAllowedOrigins("*")}, "https://example.org", true},
+	} {
+		api := httptest.NewServer(httpapi.NewManaged(m, "tv", tt.opts...))
+
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+
+		ws, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http")+"/events/ws?types=power", header)
+		if tt.ok && err != nil {
+			t.Errorf("origin %q was refused: %v", tt.origin, err)
+		}
+		if !tt.ok && (res == nil || res.StatusCode != http.StatusForbidden) {
+			t.Errorf("origin %q was allowed, want 403", tt.origin)
+		}
+		if ws != nil {
+			ws.Close()
+		}
+		api.Close()
+	}
+}
+
+func TestKeys(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	h := httpapi.NewManaged(newManager(t, srv), "tv")
+	for path, want := range map[string]int{
+		"/keys/up":          http.StatusNoContent,
+		"/keys/volumeup":    http.StatusNoContent,
+		"/keys/fastforward": http.StatusNoContent,
+		"/keys/eject":       http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		if rec.Code != want {
+			t.Errorf("POST %s returned %d, want %d", path, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package httpapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI returns the OpenAPI 3 document describing the REST API for a single TV,
+// generated from the routes.
+func OpenAPI() map[string]interface{} {
+	paths := make(map[string]interface{})
+	schemas := make(map[string]interface{})
+
+	for _, rt := range routes {
+		item, ok := paths[rt.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[rt.path] = item
+		}
+
+		op := map[string]interface{}{
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+			"responses":   responses(rt, schemas),
+		}
+
+		if params := append(pathParameters(rt.path), queryParameters(rt.query)...); len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if rt.request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": schemaOf(reflect.TypeOf(rt.request), schemas),
+					},
+				},
+			}
+		}
+
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	schemas["Error"] = schemaOf(reflect.TypeOf(errorResponse{}), nil)
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "webOS TV",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+}
+
+// operationID returns a unique operation ID for the route, e.g. `postAppsIdLaunch`.
+func operationID(rt route) string {
+	id := strings.ToLower(rt.method)
+	for _, part := range strings.FieldsFunc(rt.path, func(r rune) bool { return r == '/' || r == '{' || r == '}' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// pathParameters returns the parameters in the path, e.g. `id` in `/apps/{id}/launch`.
+func pathParameters(path string) []interface{} {
+	var params []interface{}
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params = append(params, map[string]interface{}{
+				"name":     strings.Trim(part, "{}"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+// queryParameters returns the query parameters of a route.
+func queryParameters(query map[string]string) []interface{} {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var params []interface{}
+	for _, name := range names {
+		params = append(params, map[string]interface{}{
+			"name":        name,
+			"in":          "query",
+			"description": query[name],
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	return params
+}
+
+// responses returns the responses of the route, including the error responses
+// the errors returned by the TV are mapped to.
+func responses(rt route, schemas map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+
+	if rt.response == nil {
+		res[strconv.Itoa(http.StatusNoContent)] = map[string]interface{}{"description": "Success"}
+	} else {
+		contentType := rt.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+
+		res[strconv.Itoa(http.StatusOK)] = map[string]interface{}{
+			"description": "Success",
+			"content": map[string]interface{}{
+				contentType: map[string]interface{}{
+					"schema": schemaOf(reflect.TypeOf(rt.response), schemas),
+				},
+			},
+		}
+	}
+
+	statuses := []int{
+		http.StatusBadRequest,
+		http.StatusForbidden,
+		http.StatusNotFound,
+		http.StatusNotImplemented,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	if rt.apiStatus != 0 {
+		statuses = append(statuses, rt.apiStatus)
+	}
+
+	for _, status := range statuses {
+		res[strconv.Itoa(status)] = map[string]interface{}{
+			"description": http.StatusText(status),
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+				},
+			},
+		}
+	}
+
+	return res
+}
+
+// schemaOf returns the JSON schema of t. Named struct types are added to schemas and
+// referenced, unless schemas is nil.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if schemas != nil && t.Name() != "" {
+			if _, ok := schemas[t.Name()]; !ok {
+				schemas[t.Name()] = structSchema(t, schemas)
+			}
+			return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		}
+		return structSchema(t, schemas)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the JSON schema of the struct type t.
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		props[name] = schemaOf(f.Type, schemas)
+	}
+	return map[string]interface{}{"type": "object", "properties": props}
+}
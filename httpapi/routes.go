@@ -0,0 +1,281 @@
+package httpapi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// route is a REST API endpoint. The OpenAPI document is generated from the routes.
+type route struct {
+	method  string
+	path    string
+	summary string
+
+	// request and response are zero values of the request and response body types,
+	// nil if the route has no body.
+	request  interface{}
+	response interface{}
+
+	// apiStatus is the HTTP status code for SSAP errors returned by the route which
+	// aren't in defaultCodes, e.g. launching an unknown app. http.StatusBadGateway is
+	// used if it is 0.
+	apiStatus int
+
+	// query are the query parameters of the route and their descriptions.
+	query map[string]string
+
+	handle func(tv *webos.TV, r *http.Request, body interface{}) (interface{}, error)
+
+	// stream routes write their own responses of the contentType, e.g. Server-Sent
+	// Events, instead of using handle.
+	stream      func(s *Server, w http.ResponseWriter, r *http.Request)
+	contentType string
+}
+
+// VolumeRequest is the request body of PUT /volume.
+type VolumeRequest struct {
+	Volume int `json:"volume"`
+}
+
+// MuteRequest is the request body of PUT /mute.
+type MuteRequest struct {
+	Muted bool `json:"muted"`
+}
+
+// NotifyRequest is the request body of POST /notify.
+type NotifyRequest struct {
+	Message string `json:"message"`
+}
+
+// routes are the REST API endpoints.
+var routes = []route{
+	{
+		method:   http.MethodGet,
+		path:     "/volume",
+		summary:  "Get the audio output volume",
+		response: webos.Volume{},
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return tv.GetVolume()
+		},
+	},
+	{
+		method:    http.MethodPut,
+		path:      "/volume",
+		summary:   "Set the audio output volume",
+		request:   VolumeRequest{},
+		apiStatus: http.StatusUnprocessableEntity,
+		handle: func(tv *webos.TV, r *http.Request, body interface{}) (interface{}, error) {
+			return nil, tv.SetVolume(body.(*VolumeRequest).Volume)
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/volume/up",
+		summary: "Increment the audio output volume",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.VolumeUp()
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/volume/down",
+		summary: "Decrement the audio output volume",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.VolumeDown()
+		},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/mute",
+		summary: "Mute or unmute the audio output",
+		request: MuteRequest{},
+		handle: func(tv *webos.TV, r *http.Request, body interface{}) (interface{}, error) {
+			if body.(*MuteRequest).Muted {
+				return nil, tv.Mute()
+			}
+			return nil, tv.Unmute()
+		},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/apps",
+		summary:  "List the installed apps",
+		response: []webos.InstalledApp{},
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return tv.ListApps()
+		},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/apps/current",
+		summary:  "Get the foreground app",
+		response: webos.App{},
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return tv.CurrentApp()
+		},
+	},
+	{
+		method:    http.MethodPost,
+		path:      "/apps/{id}/launch",
+		summary:   "Launch an app",
+		apiStatus: http.StatusNotFound,
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.LaunchApp(r.PathValue("id"))
+		},
+	},
+	{
+		method:    http.MethodPost,
+		path:      "/apps/{id}/close",
+		summary:   "Close an app",
+		apiStatus: http.StatusNotFound,
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.CloseApp(r.PathValue("id"))
+		},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/inputs",
+		summary:  "List the external inputs",
+		response: []webos.ExternalInput{},
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return tv.ExternalInputs()
+		},
+	},
+	{
+		method:    http.MethodPost,
+		path:      "/inputs/{id}/switch",
+		summary:   "Switch to an external input",
+		apiStatus: http.StatusNotFound,
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.SwitchInput(r.PathValue("id"))
+		},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/channels",
+		summary:  "List the channels",
+		response: webos.Payload{},
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			msg, err := tv.ChannelList()
+			return msg.Payload, err
+		},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/channels/current",
+		summary:  "Get the current channel",
+		response: webos.Payload{},
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			msg, err := tv.CurrentChannel()
+			return msg.Payload, err
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/channels/up",
+		summary: "Change the channel up",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.ChannelUp()
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/channels/down",
+		summary: "Change the channel down",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.ChannelDown()
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/keys/{button}",
+		summary: "Press a remote control button: " + strings.Join(webos.Buttons, ", "),
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.PressButton(r.PathValue("button"))
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/media/{action}",
+		summary: "Control the current media: play, pause, stop, rewind or fastforward",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, controlMedia(tv, r.PathValue("action"))
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/notify",
+		summary: "Show a toast notification",
+		request: NotifyRequest{},
+		handle: func(tv *webos.TV, r *http.Request, body interface{}) (interface{}, error) {
+			return nil, tv.Notification(body.(*NotifyRequest).Message)
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/screen/on",
+		summary: "Turn the screen on",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.ScreenOn()
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/screen/off",
+		summary: "Turn the screen off, leaving the TV running",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.ScreenOff()
+		},
+	},
+	{
+		method:      http.MethodGet,
+		path:        "/events",
+		summary:     "Stream volume, app, power, channel and media state changes as Server-Sent Events, starting with the current state",
+		response:    webos.Event{},
+		query:       map[string]string{"types": "comma separated event types to stream, e.g. volume,app. Defaults to all types"},
+		stream:      (*Server).serveEvents,
+		contentType: "text/event-stream",
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/events/ws",
+		summary:  "Stream state changes as JSON websocket messages, starting with the current state",
+		response: webos.Event{},
+		query:    map[string]string{"types": "comma separated event types to stream, e.g. volume,app. Defaults to all types"},
+		stream:   (*Server).serveEventsWebsocket,
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/power/off",
+		summary: "Turn the TV off",
+		handle: func(tv *webos.TV, r *http.Request, _ interface{}) (interface{}, error) {
+			return nil, tv.Shutdown()
+		},
+	},
+}
+
+// controlMedia performs the named media control action.
+func controlMedia(tv *webos.TV, action string) error {
+	switch normalise(action) {
+	case "play":
+		return tv.Play()
+	case "pause":
+		return tv.Pause()
+	case "stop":
+		return tv.Stop()
+	case "rewind":
+		return tv.Rewind()
+	case "fastforward":
+		return tv.FastForward()
+	default:
+		return &badRequestError{msg: "unknown media action: " + action}
+	}
+}
+
+// newOf returns a pointer to a new zero value of the type of v.
+func newOf(v interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(v)).Interface()
+}
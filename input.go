@@ -1,25 +1,59 @@
 package webos
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Input is the pointer input socket, used to send button presses and pointer moves.
+// It is safe for concurrent use; buttons and moves are sent in the order they are made.
 type Input struct {
-	ws *websocket.Conn
+	ws     Conn
+	logger *slog.Logger
+
+	// mu orders writes to the socket
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+
+	// moveMu guards the pointer movement waiting to be sent
+	moveMu sync.Mutex
+	dx, dy int
 }
 
-// NewInput dials the socket and returns a pointer to a new Input.
+// NewInput dials the socket and returns a pointer to a new Input. The TV's certificate
+// isn't verified; the Input returned by TV.Input verifies the certificate is the one
+// used by the TV's socket.
 func NewInput(uri string) (*Input, error) {
+	return newInput(uri, &tls.Config{InsecureSkipVerify: true})
+}
+
+// dryRunSocket is the socket path returned by DryRunMiddleware, for which Input
+// discards the buttons and moves sent.
+const dryRunSocket = "dryrun:"
+
+// newInput dials the socket using the TLS configuration, or without verifying the
+// certificate if nil.
+func newInput(uri string, c *tls.Config) (*Input, error) {
+	if uri == dryRunSocket {
+		return &Input{ws: discardConn{}}, nil
+	}
+
+	if c == nil {
+		c = &tls.Config{InsecureSkipVerify: true}
+	}
+
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
+		TLSClientConfig:  c,
 		NetDial: (&net.Dialer{
 			Timeout: time.Second * 5,
 		}).Dial,
@@ -36,17 +70,183 @@ func NewInput(uri string) (*Input, error) {
 	return &Input{ws: ws}, nil
 }
 
-// SendButton
+// Buttons are the names of the remote control buttons pressed by PressButton.
+var Buttons = []string{
+	"up", "down", "left", "right", "ok", "back", "home",
+	"play", "pause", "stop", "rewind", "fastforward",
+	"volumeup", "volumedown", "channelup", "channeldown",
+}
+
+// PressButton presses the named remote control button, one of Buttons, e.g. `up` or
+// `volumeup`. Names are case insensitive and `enter` is an alias for `ok`. An error
+// wrapping ErrUnknownButton is returned for other names.
+func (tv *TV) PressButton(name string) error {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "up":
+		return tv.KeyUp()
+	case "down":
+		return tv.KeyDown()
+	case "left":
+		return tv.KeyLeft()
+	case "right":
+		return tv.KeyRight()
+	case "ok", "enter":
+		_, err := tv.KeyOk()
+		return err
+	case "back":
+		return tv.KeyBack()
+	case "home":
+		return tv.KeyHome()
+	case "play":
+		return tv.Play()
+	case "pause":
+		return tv.Pause()
+	case "stop":
+		return tv.Stop()
+	case "rewind":
+		return tv.Rewind()
+	case "fastforward":
+		return tv.FastForward()
+	case "volumeup":
+		return tv.VolumeUp()
+	case "volumedown":
+		return tv.VolumeDown()
+	case "channelup":
+		return tv.ChannelUp()
+	case "channeldown":
+		return tv.ChannelDown()
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownButton, name)
+	}
+}
+
+// SendButton sends the button press, e.g. `UP` or `HOME`. Pointer moves made before
+// the button press are sent first.
 func (input *Input) SendButton(name string) error {
-	body := fmt.Sprintf("type:button\nname:%s\n\n", name)
-	err := input.ws.WriteMessage(websocket.TextMessage, []byte(body))
+	input.mu.Lock()
+	defer input.mu.Unlock()
+
+	if err := input.flushMove(); err != nil {
+		return err
+	}
+
+	input.wait()
+	err := input.write(fmt.Sprintf("type:button\nname:%s\n\n", name))
 	if err != nil {
+		input.log(slog.LevelWarn, "could not send button", slog.String("button", name), slog.Any("error", err))
+		return err
+	}
+
+	input.log(slog.LevelDebug, "send button", slog.String("button", name))
+	return nil
+}
+
+// Move moves the pointer by dx and dy. Moves made while another move is waiting to be
+// sent are coalesced, with their distances added together, so rapid moves don't
+// flood the TV.
+func (input *Input) Move(dx, dy int) error {
+	input.moveMu.Lock()
+	input.dx += dx
+	input.dy += dy
+	input.moveMu.Unlock()
+
+	input.mu.Lock()
+	defer input.mu.Unlock()
+
+	return input.flushMove()
+}
+
+// Click clicks at the pointer position.
+func (input *Input) Click() error {
+	input.mu.Lock()
+	defer input.mu.Unlock()
+
+	if err := input.flushMove(); err != nil {
+		return err
+	}
+
+	input.wait()
+	return input.write("type:click\n\n")
+}
+
+// SetRateLimit sends buttons and moves at most once per interval. Buttons wait for
+// their turn while moves are coalesced. The rate isn't limited if interval is 0.
+func (input *Input) SetRateLimit(interval time.Duration) {
+	input.mu.Lock()
+	defer input.mu.Unlock()
+
+	input.interval = interval
+}
+
+// flushMove sends the pointer movement waiting to be sent, if any. mu must be held.
+func (input *Input) flushMove() error {
+	input.moveMu.Lock()
+	pending := input.dx != 0 || input.dy != 0
+	input.moveMu.Unlock()
+
+	if !pending {
+		return nil
+	}
+
+	// moves made while waiting are added to this move
+	input.wait()
+
+	input.moveMu.Lock()
+	dx, dy := input.dx, input.dy
+	input.dx, input.dy = 0, 0
+	input.moveMu.Unlock()
+
+	if err := input.write(fmt.Sprintf("type:move\ndx:%d\ndy:%d\ndown:0\n\n", dx, dy)); err != nil {
+		return err
+	}
+
+	input.log(slog.LevelDebug, "send move", slog.Int("dx", dx), slog.Int("dy", dy))
+	return nil
+}
+
+// wait waits until the rate limit allows the next write. mu must be held.
+func (input *Input) wait() {
+	if input.interval > 0 {
+		time.Sleep(time.Until(input.last.Add(input.interval)))
+	}
+}
+
+// write writes the body to the socket. mu must be held.
+func (input *Input) write(body string) error {
+	input.last = time.Now()
+	if err := input.ws.WriteMessage(websocket.TextMessage, []byte(body)); err != nil {
 		return fmt.Errorf("could not write to socket: %v", err)
 	}
 	return nil
 }
 
+// discardConn is a Conn discarding the Messages written, used by a dry run.
+type discardConn struct{}
+
+func (discardConn) ReadMessage() (int, []byte, error) { return 0, nil, ErrConnectionClosed }
+func (discardConn) WriteMessage(int, []byte) error    { return nil }
+func (discardConn) Close() error                      { return nil }
+
 // Close closes the websocket connection.
 func (input *Input) Close() error {
+	input.log(slog.LevelInfo, "closing input connection")
 	return input.ws.Close()
 }
+
+// SetLogger sets the handler the buttons sent and the connection lifecycle are
+// logged to. Logging is disabled if h is nil.
+func (input *Input) SetLogger(h slog.Handler) {
+	if h == nil {
+		input.logger = nil
+		return
+	}
+	input.logger = slog.New(h).With(slog.String("socket", "input"))
+}
+
+// log logs the message and attributes if a logger is set.
+func (input *Input) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if input.logger == nil {
+		return
+	}
+	input.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
@@ -0,0 +1,446 @@
+// Command ssapgen generates the Commands, their permissions and minimum webOS
+// versions, and the typed endpoints and methods of the webos package, and the stubs of
+// the webostest package, from the SSAP spec. It's run by `go generate` in the
+// repository root:
+//
+//	go run ./internal/ssapgen -spec spec/ssap.yaml -out commands_gen.go -stubs webostest/stubs_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// docWidth is the width doc comments are wrapped to.
+const docWidth = 90
+
+// Spec is the SSAP spec.
+type Spec struct {
+	Types     []Type     `yaml:"types"`
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+// Type is a struct nested in responses.
+type Type struct {
+	Name   string  `yaml:"name"`
+	Doc    string  `yaml:"doc"`
+	Fields []Field `yaml:"fields"`
+}
+
+// Endpoint is an SSAP endpoint.
+type Endpoint struct {
+	Name       string                 `yaml:"name"`
+	URI        string                 `yaml:"uri"`
+	Doc        string                 `yaml:"doc"`
+	Permission string                 `yaml:"permission"`
+	Since      int                    `yaml:"since"`
+	Feature    string                 `yaml:"feature"`
+	Method     string                 `yaml:"method"`
+	MethodDoc  string                 `yaml:"method_doc"`
+	Request    []Field                `yaml:"request"`
+	Response   *Response              `yaml:"response"`
+	Example    map[string]interface{} `yaml:"example"`
+}
+
+// Response is the response of an Endpoint.
+type Response struct {
+	Type   string  `yaml:"type"`
+	Fields []Field `yaml:"fields"`
+}
+
+// Field is a field of a request or response.
+type Field struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+	Type string `yaml:"type"`
+}
+
+// command returns the name of the Endpoint's Command constant.
+func (e *Endpoint) command() string {
+	return e.Name + "Command"
+}
+
+// requestType returns the name of the Endpoint's request type.
+func (e *Endpoint) requestType() string {
+	if len(e.Request) == 0 {
+		return "Empty"
+	}
+	return e.Method + "Request"
+}
+
+// responseType returns the name of the Endpoint's response type.
+func (e *Endpoint) responseType() string {
+	if e.Response == nil {
+		return "Empty"
+	}
+	return e.Response.Type
+}
+
+func main() {
+	specPath := flag.String("spec", "spec/ssap.yaml", "path of the SSAP spec")
+	out := flag.String("out", "commands_gen.go", "path of the generated webos package file")
+	stubs := flag.String("stubs", "webostest/stubs_gen.go", "path of the generated webostest package file")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("ssapgen: ")
+
+	spec, err := load(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := write(*out, generateCommands(spec, *specPath)); err != nil {
+		log.Fatal(err)
+	}
+	if err := write(*stubs, generateStubs(spec, *specPath)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// load reads and validates the spec.
+func load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec Spec
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if err := validate(&spec); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// validate checks the names, URIs and types of the spec.
+func validate(spec *Spec) error {
+	types := map[string]bool{"string": true, "int": true, "float64": true, "bool": true}
+	for _, t := range spec.Types {
+		if t.Name == "" {
+			return fmt.Errorf("type without a name")
+		}
+		types[t.Name] = true
+	}
+
+	checkFields := func(name string, fields []Field) error {
+		for _, f := range fields {
+			if f.Name == "" || f.Key == "" {
+				return fmt.Errorf("%s: field without a name or key", name)
+			}
+			if !types[f.Type] {
+				return fmt.Errorf("%s: field %s has unknown type %q", name, f.Name, f.Type)
+			}
+		}
+		return nil
+	}
+
+	for _, t := range spec.Types {
+		if err := checkFields(t.Name, t.Fields); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, e := range spec.Endpoints {
+		switch {
+		case e.Name == "":
+			return fmt.Errorf("endpoint %s without a name", e.URI)
+		case names[e.Name]:
+			return fmt.Errorf("endpoint %s declared twice", e.Name)
+		case !strings.HasPrefix(e.URI, "ssap://"):
+			return fmt.Errorf("%s: URI %q isn't an SSAP URI", e.Name, e.URI)
+		case e.Doc == "":
+			return fmt.Errorf("%s: missing doc", e.Name)
+		case e.Method == "" && (e.Request != nil || e.Response != nil):
+			return fmt.Errorf("%s: request or response without a method", e.Name)
+		case e.Method != "" && e.MethodDoc == "":
+			return fmt.Errorf("%s: missing method_doc", e.Name)
+		case e.Response != nil && e.Response.Type == "":
+			return fmt.Errorf("%s: response without a type", e.Name)
+		}
+		names[e.Name] = true
+
+		if err := checkFields(e.Name, e.Request); err != nil {
+			return err
+		}
+		if e.Response != nil {
+			if err := checkFields(e.Name, e.Response.Fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// generateCommands generates the webos package file.
+func generateCommands(spec *Spec, specPath string) []byte {
+	var buf bytes.Buffer
+	header(&buf, specPath, "webos")
+
+	buf.WriteString("const (\n")
+	for i, e := range spec.Endpoints {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		comment(&buf, "\t", e.command()+" "+e.Doc)
+		fmt.Fprintf(&buf, "\t%s Command = %q\n", e.command(), e.URI)
+	}
+	buf.WriteString(")\n\n")
+
+	// several Commands may share a URI, e.g. AudioVolumeStatusCommand, so the maps are
+	// keyed by the first
+	endpoints := uniqueURIs(spec.Endpoints)
+
+	buf.WriteString("// commandPermissions are the permissions required to execute Commands.\n")
+	buf.WriteString("var commandPermissions = map[Command]string{\n")
+	for _, e := range endpoints {
+		if e.Permission != "" {
+			fmt.Fprintf(&buf, "\t%s: %q,\n", e.command(), e.Permission)
+		}
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// minimumVersions are the first webOS major versions supporting Commands.\n")
+	buf.WriteString("var minimumVersions = map[Command]int{\n")
+	for _, e := range endpoints {
+		if e.Since > 1 {
+			fmt.Fprintf(&buf, "\t%s: %d,\n", e.command(), e.Since)
+		}
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// commandFeatures are the system information features required by Commands.\n")
+	buf.WriteString("var commandFeatures = map[Command]string{\n")
+	for _, e := range endpoints {
+		if e.Feature != "" {
+			fmt.Fprintf(&buf, "\t%s: %q,\n", e.command(), e.Feature)
+		}
+	}
+	buf.WriteString("}\n")
+
+	for _, t := range spec.Types {
+		buf.WriteString("\n")
+		comment(&buf, "", t.Name+" "+t.Doc)
+		structType(&buf, t.Name, t.Fields)
+	}
+
+	for _, e := range spec.Endpoints {
+		if e.Method == "" {
+			continue
+		}
+
+		if len(e.Request) > 0 {
+			buf.WriteString("\n")
+			comment(&buf, "", fmt.Sprintf("%s is the request of %s.", e.requestType(), e.command()))
+			structType(&buf, e.requestType(), e.Request)
+		}
+		if e.Response != nil {
+			buf.WriteString("\n")
+			comment(&buf, "", fmt.Sprintf("%s is the response of %s.", e.Response.Type, e.command()))
+			structType(&buf, e.Response.Type, e.Response.Fields)
+		}
+	}
+
+	buf.WriteString("\n// Endpoints of the Commands with generated methods.\nvar (\n")
+	for _, e := range spec.Endpoints {
+		if e.Method != "" {
+			fmt.Fprintf(&buf, "\t%sEndpoint = NewEndpoint[%s, %s](%s)\n", e.Method, e.requestType(), e.responseType(), e.command())
+		}
+	}
+	buf.WriteString(")\n")
+
+	for _, e := range spec.Endpoints {
+		if e.Method != "" {
+			buf.WriteString("\n")
+			method(&buf, &e)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// method writes the TV method calling the Endpoint.
+func method(buf *bytes.Buffer, e *Endpoint) {
+	var params, values []string
+	for _, f := range e.Request {
+		name := argument(f.Name)
+		params = append(params, name+" "+f.Type)
+		values = append(values, f.Name+": "+name)
+	}
+
+	req := "Empty{}"
+	if len(e.Request) > 0 {
+		req = e.requestType() + "{" + strings.Join(values, ", ") + "}"
+	}
+
+	comment(buf, "", e.Method+" "+e.MethodDoc)
+	if e.Response != nil {
+		fmt.Fprintf(buf, "func (tv *TV) %s(%s) (*%s, error) {\n", e.Method, strings.Join(params, ", "), e.Response.Type)
+		fmt.Fprintf(buf, "\treturn call(tv, %sEndpoint, %s)\n}\n", e.Method, req)
+		return
+	}
+
+	fmt.Fprintf(buf, "func (tv *TV) %s(%s) error {\n", e.Method, strings.Join(params, ", "))
+	fmt.Fprintf(buf, "\t_, err := call(tv, %sEndpoint, %s)\n\treturn err\n}\n", e.Method, req)
+}
+
+// generateStubs generates the webostest package file.
+func generateStubs(spec *Spec, specPath string) []byte {
+	var buf bytes.Buffer
+	header(&buf, specPath, "webostest")
+
+	buf.WriteString("import webos \"github.com/kaperys/go-webos\"\n\n")
+	buf.WriteString("// stubs are the example responses of the Commands, served by RespondStubs.\n")
+	buf.WriteString("var stubs = map[webos.Command]webos.Payload{\n")
+	for _, e := range uniqueURIs(spec.Endpoints) {
+		// Commands without an example succeed without a response Payload
+		fmt.Fprintf(&buf, "\twebos.%s: %s,\n", e.command(), literal(e.Example))
+	}
+	buf.WriteString("}\n")
+
+	return buf.Bytes()
+}
+
+// header writes the generated file header and package clause.
+func header(buf *bytes.Buffer, specPath, pkg string) {
+	fmt.Fprintf(buf, "// Code generated by ssapgen from %s. DO NOT EDIT.\n\n", specPath)
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+}
+
+// structType writes the struct type with the fields, tagged with their keys.
+func structType(buf *bytes.Buffer, name string, fields []Field) {
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(buf, "\t%s %s `mapstructure:%q json:%q`\n", f.Name, f.Type, f.Key, f.Key)
+	}
+	buf.WriteString("}\n")
+}
+
+// comment writes the text as a doc comment wrapped to docWidth.
+func comment(buf *bytes.Buffer, indent, text string) {
+	width := docWidth - len(indent) - len("// ")
+
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	fmt.Fprintf(buf, "%s// %s\n", indent, line)
+}
+
+// literalWidth is the width above which the elements of composite literals are written
+// on separate lines.
+const literalWidth = 60
+
+// literal returns the Go composite literal of the example value, without its type.
+func literal(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		elems := make([]string, len(keys))
+		for i, k := range keys {
+			elems[i] = strconv.Quote(k) + ": " + value(v[k])
+		}
+		return composite(elems)
+
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			elems[i] = value(e)
+		}
+		return composite(elems)
+	}
+
+	return value(v)
+}
+
+// composite returns the composite literal of the elements, on one line unless it's
+// wider than literalWidth.
+func composite(elems []string) string {
+	line := "{" + strings.Join(elems, ", ") + "}"
+	if len(line) <= literalWidth && !strings.Contains(line, "\n") {
+		return line
+	}
+	return "{\n" + strings.Join(elems, ",\n") + ",\n}"
+}
+
+// value returns the Go expression of the example value.
+func value(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "map[string]interface{}" + literal(v)
+	case []interface{}:
+		return "[]interface{}" + literal(v)
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "nil"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// uniqueURIs returns the endpoints, omitting those with the URI of an earlier endpoint.
+func uniqueURIs(endpoints []Endpoint) []Endpoint {
+	seen := make(map[string]bool)
+
+	var unique []Endpoint
+	for _, e := range endpoints {
+		if !seen[e.URI] {
+			seen[e.URI] = true
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
+
+// argument returns the method argument name of the field, e.g. channelID for ChannelID.
+func argument(field string) string {
+	r := []rune(field)
+	for i := range r {
+		// lower the leading upper case letters, but not the first letter of the next word
+		if i > 0 && i+1 < len(r) && unicode.IsUpper(r[i]) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(r[i]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// write formats the source and writes it to path.
+func write(path string, src []byte) error {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("could not format %s: %w\n%s", path, err, src)
+	}
+	return os.WriteFile(path, formatted, 0644)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerated checks the generated files are up to date with the spec, i.e. that
+// `go generate` was run after the spec was last edited.
+func TestGenerated(t *testing.T) {
+	root := filepath.Join("..", "..")
+	spec, err := load(filepath.Join(root, "spec", "ssap.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, src := range map[string][]byte{
+		"commands_gen.go":        generateCommands(spec, "spec/ssap.yaml"),
+		"webostest/stubs_gen.go": generateStubs(spec, "spec/ssap.yaml"),
+	} {
+		want, err := format.Source(src)
+		if err != nil {
+			t.Fatalf("could not format %s: %v", path, err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date with the spec, run go generate in the repository root", path)
+		}
+	}
+}
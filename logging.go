@@ -0,0 +1,128 @@
+package webos
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SetLogger sets the handler the SSAP protocol is logged to: the connection lifecycle,
+// every Message sent and received with its latency, and Messages which are dropped or
+// can't be decoded. Messages are logged at the debug level, with client keys and PINs
+// redacted. Logging is disabled if h is nil.
+//
+// The logger is also used by the pointer input socket. It should be set before the TV
+// is used.
+func (tv *TV) SetLogger(h slog.Handler) {
+	if h == nil {
+		tv.logger = nil
+	} else {
+		tv.logger = slog.New(h)
+	}
+
+	tv.inputMutex.Lock()
+	if tv.input != nil {
+		tv.input.SetLogger(h)
+	}
+	tv.inputMutex.Unlock()
+}
+
+// log logs the message and attributes if a logger is set.
+func (tv *TV) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if tv.logger == nil {
+		return
+	}
+	tv.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// logMessage logs a Message sent to or received from the TV, with the latency of
+// responses.
+func (tv *TV) logMessage(event string, msg Message, sent time.Time) {
+	if tv.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("type", string(msg.Type)),
+		slog.String("id", msg.ID),
+	}
+	if msg.URI != "" {
+		attrs = append(attrs, slog.String("uri", string(msg.URI)))
+	}
+	if !sent.IsZero() {
+		attrs = append(attrs, slog.Duration("latency", time.Since(sent)))
+	}
+	if msg.Error != "" {
+		attrs = append(attrs, slog.String("error", msg.Error))
+	}
+	if msg.Payload != nil {
+		attrs = append(attrs, slog.Any("payload", Redact(msg.Payload)))
+	}
+
+	tv.log(slog.LevelDebug, event, attrs...)
+}
+
+// Redacted replaces the client keys and PINs redacted by Redact.
+const Redacted = "REDACTED"
+
+// redactedKeys are the Payload keys whose values are secrets, at any depth.
+var redactedKeys = map[string]bool{"client-key": true, "pin": true}
+
+// Redact returns the Payload with the client keys and PINs at any depth replaced by
+// Redacted, copying the maps and slices which contain them. p isn't modified.
+func Redact(p Payload) Payload {
+	if r, ok := redactMap(p); ok {
+		return r
+	}
+	return p
+}
+
+// redactMap returns a copy of m with its secrets replaced, and true, if it contains any.
+func redactMap(m map[string]interface{}) (map[string]interface{}, bool) {
+	var r map[string]interface{}
+	for k, v := range m {
+		if redactedKeys[k] {
+			v = Redacted
+		} else if rv, ok := redactValue(v); ok {
+			v = rv
+		} else {
+			continue
+		}
+
+		if r == nil {
+			r = make(map[string]interface{}, len(m))
+			for k, v := range m {
+				r[k] = v
+			}
+		}
+		r[k] = v
+	}
+	return r, r != nil
+}
+
+// redactValue returns a copy of the nested map or slice with its secrets replaced,
+// and true, if it contains any.
+func redactValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case Payload:
+		if r, ok := redactMap(v); ok {
+			return Payload(r), true
+		}
+	case map[string]interface{}:
+		return redactMap(v)
+	case []interface{}:
+		var r []interface{}
+		for i, e := range v {
+			re, ok := redactValue(e)
+			if !ok {
+				continue
+			}
+			if r == nil {
+				r = append([]interface{}(nil), v...)
+			}
+			r[i] = re
+		}
+		return r, r != nil
+	}
+	return nil, false
+}
@@ -0,0 +1,69 @@
+package webos_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// syncBuffer is a bytes.Buffer which is safe for concurrent use, as the
+// MessageHandler logs while the test reads what was logged.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogging(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"volume": 10})
+
+	var buf syncBuffer
+	tv, err := webos.Dial(srv.Host(), webos.WithDialer(srv.Dialer()),
+		webos.WithLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer tv.Close()
+	go tv.MessageHandler()
+
+	if _, err := tv.AuthorisePIN(func() (string, error) { return webostest.DefaultPIN, nil }); err != nil {
+		t.Fatalf("AuthorisePIN: %v", err)
+	}
+	// secrets nested in the payload
+	if _, err := tv.Command(webos.AudioGetVolumeCommand, webos.Payload{
+		"params": map[string]interface{}{"credentials": []interface{}{map[string]interface{}{"client-key": "nested-key"}}},
+	}); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	logged := buf.String()
+	if !strings.Contains(logged, `"msg":"connected"`) {
+		t.Errorf("the connection wasn't logged:\n%s", logged)
+	}
+	for _, secret := range []string{webostest.DefaultClientKey, webostest.DefaultPIN, "nested-key"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("%q was logged:\n%s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "REDACTED") {
+		t.Errorf("no secrets were redacted:\n%s", logged)
+	}
+}
@@ -0,0 +1,218 @@
+package webos
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// setSystemSettingsURI is the luna URI of the settings service method which writes
+// settings, which isn't exposed over SSAP.
+const setSystemSettingsURI = "luna://com.webos.settingsservice/setSystemSettings"
+
+// Luna calls the luna URI, e.g. `luna://com.webos.settingsservice/setSystemSettings`,
+// with the params. Luna services aren't exposed over SSAP, so an alert is created which
+// calls the URI when it's closed, and is closed straight away. The TV doesn't return
+// the luna service's response, so only the errors creating and closing the alert are
+// returned.
+func (tv *TV) Luna(uri string, params Payload) error {
+	if !strings.HasPrefix(uri, "luna://") {
+		return errors.Errorf("not a luna URI: %s", uri)
+	}
+	if params == nil {
+		params = Payload{}
+	}
+
+	call := map[string]interface{}{"uri": uri, "params": params}
+	msg, err := tv.Command(SystemNotificationsCreateAlertCommand, Payload{
+		"message": " ",
+		"buttons": []interface{}{
+			map[string]interface{}{"label": "", "onClick": uri, "params": params},
+		},
+		"onclose": call,
+		"onfail":  call,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not create alert calling %s", uri)
+	}
+
+	id, ok := msg.Payload["alertId"].(string)
+	if !ok || id == "" {
+		return errors.New("createAlert response has no alert ID")
+	}
+
+	if _, err := tv.Command(SystemNotificationsCloseAlertCommand, Payload{"alertId": id}); err != nil {
+		return errors.Wrapf(err, "could not close alert calling %s", uri)
+	}
+	return nil
+}
+
+// PictureSettings are the settings of the `picture` category. Numeric settings are
+// between 0 and 100.
+type PictureSettings struct {
+	PictureMode    string `mapstructure:"pictureMode" json:"pictureMode"`
+	Backlight      int    `mapstructure:"backlight" json:"backlight"`
+	Brightness     int    `mapstructure:"brightness" json:"brightness"`
+	Contrast       int    `mapstructure:"contrast" json:"contrast"`
+	Color          int    `mapstructure:"color" json:"color"`
+	EnergySaving   string `mapstructure:"energySaving" json:"energySaving"`
+	EyeComfortMode string `mapstructure:"eyeComfortMode" json:"eyeComfortMode"`
+}
+
+// pictureSettingsKeys are the keys of the PictureSettings.
+var pictureSettingsKeys = []string{
+	"pictureMode", "backlight", "brightness", "contrast", "color", "energySaving", "eyeComfortMode",
+}
+
+// OptionSettings are the settings of the `option` category.
+type OptionSettings struct {
+	// AudioGuidance is whether the menus are read out loud.
+	AudioGuidance bool `mapstructure:"audioGuidance" json:"audioGuidance"`
+
+	// QuickStartMode is whether the TV is kept in standby when it's turned off, so
+	// it turns on faster.
+	QuickStartMode bool `mapstructure:"quickStartMode" json:"quickStartMode"`
+
+	// StoreMode is `home`, or `store` for the demo mode of TVs on display.
+	StoreMode string `mapstructure:"storeMode" json:"storeMode"`
+
+	// Country is the ISO 3166-1 alpha-3 code of the TV's country, e.g. `GBR`.
+	Country string `mapstructure:"country" json:"country"`
+}
+
+// optionSettingsKeys are the keys of the OptionSettings.
+var optionSettingsKeys = []string{"audioGuidance", "quickStartMode", "storeMode", "country"}
+
+// SystemSettings returns the settings of the category, e.g. `picture` or `option`,
+// with the keys, e.g. `backlight`. The TV returns most settings as strings.
+func (tv *TV) SystemSettings(category string, keys ...string) (Payload, error) {
+	msg, err := tv.Command(SettingsGetSystemSettingsCommand, Payload{"category": category, "keys": keys})
+	if err != nil {
+		return nil, err
+	}
+
+	settings, ok := msg.Payload["settings"].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("no %s settings in response", category)
+	}
+	return Payload(settings), nil
+}
+
+// SetSystemSettings writes the settings of the category, e.g.
+// `tv.SetSystemSettings("picture", webos.Payload{"backlight": 50})`, using Luna.
+func (tv *TV) SetSystemSettings(category string, settings Payload) error {
+	return tv.Luna(setSystemSettingsURI, Payload{"category": category, "settings": settings})
+}
+
+// PictureSettings returns the PictureSettings.
+func (tv *TV) PictureSettings() (*PictureSettings, error) {
+	settings, err := tv.SystemSettings("picture", pictureSettingsKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ps PictureSettings
+	if err := decodeSettings(settings, &ps); err != nil {
+		return nil, errors.Wrap(err, "could not decode picture settings")
+	}
+	return &ps, nil
+}
+
+// SetPictureMode sets the picture mode, e.g. `cinema`, `eco` or `vivid`.
+func (tv *TV) SetPictureMode(mode string) error {
+	return tv.SetSystemSettings("picture", Payload{"pictureMode": mode})
+}
+
+// SetBacklight sets the backlight, between 0 and 100.
+func (tv *TV) SetBacklight(v int) error {
+	return tv.SetSystemSettings("picture", Payload{"backlight": v})
+}
+
+// SetEnergySaving sets the energy saving mode, e.g. `off`, `min`, `med`, `max`,
+// `auto` or `screen_off`.
+func (tv *TV) SetEnergySaving(mode string) error {
+	return tv.SetSystemSettings("picture", Payload{"energySaving": mode})
+}
+
+// SetEyeComfort turns the eye comfort mode, which reduces blue light, on or off.
+func (tv *TV) SetEyeComfort(on bool) error {
+	return tv.SetSystemSettings("picture", Payload{"eyeComfortMode": OnOff(on)})
+}
+
+// SetHDMIDeepColor turns HDMI Ultra HD Deep Colour of the HDMI port, e.g. 1 for
+// `HDMI_1`, on or off.
+func (tv *TV) SetHDMIDeepColor(port int, on bool) error {
+	return tv.SetSystemSettings("other", Payload{fmt.Sprintf("uhdDeepColorHDMI%d", port): OnOff(on)})
+}
+
+// OptionSettings returns the OptionSettings.
+func (tv *TV) OptionSettings() (*OptionSettings, error) {
+	settings, err := tv.SystemSettings("option", optionSettingsKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var o OptionSettings
+	if err := decodeSettings(settings, &o); err != nil {
+		return nil, errors.Wrap(err, "could not decode option settings")
+	}
+	return &o, nil
+}
+
+// SetAudioGuidance turns audio guidance, which reads the menus out loud, on or off.
+func (tv *TV) SetAudioGuidance(on bool) error {
+	return tv.SetSystemSettings("option", Payload{"audioGuidance": OnOff(on)})
+}
+
+// SetQuickStart turns the quick start mode, which keeps the TV in standby when it's
+// turned off, on or off.
+func (tv *TV) SetQuickStart(on bool) error {
+	return tv.SetSystemSettings("option", Payload{"quickStartMode": OnOff(on)})
+}
+
+// SetStoreMode sets the store mode, `home` or `store`.
+func (tv *TV) SetStoreMode(mode string) error {
+	return tv.SetSystemSettings("option", Payload{"storeMode": mode})
+}
+
+// decodeSettings decodes the settings into v, decoding on/off settings into bools.
+func decodeSettings(settings Payload, v interface{}) error {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		DecodeHook: func(from, to reflect.Kind, data interface{}) (interface{}, error) {
+			if s, ok := data.(string); ok && from == reflect.String && to == reflect.Bool {
+				return ParseOnOff(s)
+			}
+			return data, nil
+		},
+		Result: v,
+	})
+	if err != nil {
+		return err
+	}
+	return dec.Decode(map[string]interface{}(settings))
+}
+
+// OnOff returns "on" if on is true, otherwise "off", the values of on/off settings.
+func OnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// ParseOnOff parses the value of an on/off setting, "on" or "off", as well as "true"
+// or "false".
+func ParseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	default:
+		return false, errors.Errorf("expected on or off: %s", s)
+	}
+}
@@ -0,0 +1,55 @@
+package webos_test
+
+import (
+	"testing"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+func TestOptionSettings(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.SettingsGetSystemSettingsCommand, webos.Payload{
+		"category": "option",
+		"settings": map[string]interface{}{"audioGuidance": "on", "quickStartMode": "off", "storeMode": "home", "country": "GBR"},
+	})
+
+	tv := dialUsing(t, srv)
+	settings, err := tv.OptionSettings()
+	if err != nil {
+		t.Fatalf("OptionSettings: %v", err)
+	}
+
+	want := webos.OptionSettings{AudioGuidance: true, QuickStartMode: false, StoreMode: "home", Country: "GBR"}
+	if *settings != want {
+		t.Errorf("OptionSettings returned %+v, want %+v", *settings, want)
+	}
+}
+
+func TestSetAudioGuidance(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	tv := dialUsing(t, srv)
+	if err := tv.SetAudioGuidance(true); err != nil {
+		t.Fatalf("SetAudioGuidance: %v", err)
+	}
+
+	for _, msg := range srv.Messages() {
+		if msg.URI != webos.SystemNotificationsCreateAlertCommand {
+			continue
+		}
+
+		// the alert calls the settings service when it's closed
+		call, _ := msg.Payload["onclose"].(map[string]interface{})
+		params, _ := call["params"].(map[string]interface{})
+		settings, _ := params["settings"].(map[string]interface{})
+		if params["category"] != "option" || settings["audioGuidance"] != "on" {
+			t.Errorf("the alert calls %v with %v, want audioGuidance on", call["uri"], params)
+		}
+		return
+	}
+	t.Error("no alert was created")
+}
@@ -0,0 +1,405 @@
+package webos
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// DeviceConfig configures a TV, e.g. one managed by a Manager. It's also the device
+// configuration of the config package, so it's tagged for JSON and YAML.
+type DeviceConfig struct {
+	// Address is the IP address or host name of the TV.
+	Address string `json:"address" yaml:"address"`
+
+	// MAC is the MAC address of the TV, used to turn it on with Wake-on-LAN.
+	MAC string `json:"mac,omitempty" yaml:"mac,omitempty"`
+
+	// ClientKey is the client key returned when pairing with the TV.
+	ClientKey string `json:"client_key,omitempty" yaml:"client_key,omitempty"`
+
+	// Fingerprint is the fingerprint of the TV's certificate, recorded when pairing.
+	// If set, it's pinned using WithCertificatePin, so connections to a TV with a
+	// different certificate fail with ErrCertificateChanged.
+	Fingerprint string `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+
+	// TLS is how the connection is secured. It defaults to TLSInsecure.
+	TLS TLSMode `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// Manifest is the name of the ManifestPresets the TV was paired with, which sets
+	// the permissions granted when registering with the ClientKey. It defaults to
+	// DefaultManifestPreset.
+	Manifest string `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+
+	// Apps and Inputs are aliases of app and external input IDs, e.g.
+	// "youtube: youtube.leanback.v4", used by the config package and the commands.
+	Apps   map[string]string `json:"apps,omitempty" yaml:"apps,omitempty"`
+	Inputs map[string]string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// Validate checks the configuration, returning the first problem found.
+func (c DeviceConfig) Validate() error {
+	if c.Address == "" {
+		return errors.New("no address")
+	}
+	if c.MAC != "" {
+		if _, err := net.ParseMAC(c.MAC); err != nil {
+			return errors.Wrap(err, "invalid MAC address")
+		}
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+	if c.Fingerprint != "" && c.TLS == TLSNone {
+		return errors.New("fingerprint can't be verified with TLS mode none")
+	}
+	if _, ok := ManifestPresets[c.Manifest]; c.Manifest != "" && !ok {
+		return errors.Errorf("unknown manifest preset: %s", c.Manifest)
+	}
+	return nil
+}
+
+// DialOptions returns the Options connecting to the TV as configured: using its
+// TLSMode, and pinning its Fingerprint.
+//
+// The TV's certificate is expected to be pinned on first use, by storing its
+// CertificateFingerprint, so TVs using TLSInsecure without a Fingerprint don't fall
+// back to ws when the TV refuses the wss connection. TVs which only support ws need
+// TLSNone.
+func (c DeviceConfig) DialOptions() []Option {
+	opts := c.TLS.options()
+	switch {
+	case c.Fingerprint != "":
+		opts = append(opts, WithCertificatePin(c.Fingerprint))
+	case c.TLS == "" || c.TLS == TLSInsecure:
+		opts = append(opts, WithoutFallback())
+	}
+	return opts
+}
+
+// Manager holds a set of named TVs, connecting to each when it's first used and
+// reconnecting once the connection is lost. It is safe for concurrent use.
+type Manager struct {
+	// Dialer is used to connect to the TVs. If nil, the TVs are connected to as by
+	// Dial, without options.
+	Dialer *websocket.Dialer
+
+	// Dial, if set, connects to the TVs instead of dialing with the Dialer, e.g. to
+	// configure each TV differently.
+	Dial func(name string, cfg DeviceConfig) (*TV, error)
+
+	// Setup, if set, is called with each TV once connected and before authorising,
+	// e.g. to register Middleware or set a logger.
+	Setup func(name string, tv *TV)
+
+	// KeepAlive, if positive, is how often the connection to each TV is checked once
+	// it has been used, by making a request. TVs which don't respond are disconnected,
+	// and TVs whose connection is lost are reconnected at the same interval, so they're
+	// ready before they're next used.
+	KeepAlive time.Duration
+
+	mu      sync.Mutex
+	devices map[string]*managedTV
+}
+
+// managedTV is the connection state of a TV in a Manager.
+type managedTV struct {
+	cfg DeviceConfig
+
+	mu   sync.Mutex
+	tv   *TV
+	done chan struct{}
+
+	// stop stops the keepalive, if it's running
+	stop chan struct{}
+}
+
+// NewManager returns a Manager for the named devices.
+func NewManager(devices map[string]DeviceConfig) *Manager {
+	m := &Manager{devices: make(map[string]*managedTV, len(devices))}
+	for name, cfg := range devices {
+		m.devices[name] = &managedTV{cfg: cfg}
+	}
+	return m
+}
+
+// Add adds or replaces the named device. A replaced device's connection is closed.
+func (m *Manager) Add(name string, cfg DeviceConfig) {
+	m.mu.Lock()
+	old := m.devices[name]
+	m.devices[name] = &managedTV{cfg: cfg}
+	m.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+}
+
+// Names returns the sorted names of the devices.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.devices))
+	for name := range m.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config returns the configuration of the named device.
+func (m *Manager) Config(name string) (DeviceConfig, bool) {
+	d, err := m.device(name)
+	if err != nil {
+		return DeviceConfig{}, false
+	}
+	return d.cfg, true
+}
+
+// TV returns the named TV, connected and authorised. The TV is connected when first
+// used, and reconnected if the connection has been lost since.
+func (m *Manager) TV(name string) (*TV, error) {
+	d, err := m.device(name)
+	if err != nil {
+		return nil, err
+	}
+	return m.tv(name, d, nil)
+}
+
+// tv returns the device's TV, connecting to it if needed, unless stop is closed.
+func (m *Manager) tv(name string, d *managedTV, stop <-chan struct{}) (*TV, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	select {
+	case <-stop:
+		// the device was closed while reconnecting
+		return nil, errors.Errorf("%s is closed", name)
+	default:
+	}
+
+	if d.tv != nil {
+		select {
+		case <-d.done:
+			// the connection was lost
+			d.tv.Close()
+			d.tv = nil
+		default:
+			return d.tv, nil
+		}
+	}
+
+	tv, err := m.connect(name, d.cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to %s", name)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tv.MessageHandler()
+	}()
+
+	if err := tv.AuthoriseClientKey(d.cfg.ClientKey); err != nil {
+		tv.Close()
+		return nil, errors.Wrapf(err, "could not authorise with %s", name)
+	}
+
+	d.tv, d.done = tv, done
+
+	if m.KeepAlive > 0 && d.stop == nil {
+		d.stop = make(chan struct{})
+		go m.keepAlive(name, d, d.stop)
+	}
+	return tv, nil
+}
+
+// keepAlive checks the connection to the device's TV every KeepAlive, reconnecting
+// if it's lost, until stop is closed.
+func (m *Manager) keepAlive(name string, d *managedTV, stop <-chan struct{}) {
+	t := time.NewTicker(m.KeepAlive)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+
+		d.mu.Lock()
+		tv, done := d.tv, d.done
+		d.mu.Unlock()
+
+		if tv != nil {
+			select {
+			case <-done:
+			default:
+				if tv.ping() == nil {
+					continue
+				}
+
+				// the TV isn't responding
+				tv.Close()
+				<-done
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// the TV is unreachable while it's off, and reconnected once it's turned on
+		m.tv(name, d, stop)
+	}
+}
+
+// ping checks the TV responds to requests. Error responses show the connection
+// works too.
+func (tv *TV) ping() error {
+	_, err := tv.requestContext(context.Background(), &Message{
+		Type: RequestMessageType,
+		ID:   tv.ids.next(),
+		URI:  APIServiceListCommand,
+	})
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return nil
+	}
+	return err
+}
+
+// connect dials the TV.
+func (m *Manager) connect(name string, cfg DeviceConfig) (*TV, error) {
+	var tv *TV
+	var err error
+	if m.Dial != nil {
+		tv, err = m.Dial(name, cfg)
+	} else {
+		var opts []Option
+		if m.Dialer != nil {
+			opts = append(opts, WithDialer(m.Dialer))
+		}
+		tv, err = Dial(cfg.Address, append(opts, cfg.DialOptions()...)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Manifest != "" {
+		if err := tv.SetManifestPreset(cfg.Manifest); err != nil {
+			tv.Close()
+			return nil, err
+		}
+	}
+
+	if m.Setup != nil {
+		m.Setup(name, tv)
+	}
+	return tv, nil
+}
+
+// Wake turns the named TV on with Wake-on-LAN. The TV can be used once it has
+// started, which can take several seconds.
+func (m *Manager) Wake(name string) error {
+	d, err := m.device(name)
+	if err != nil {
+		return err
+	}
+
+	if d.cfg.MAC == "" {
+		return errors.Errorf("no MAC address for %s", name)
+	}
+	return WakeOnLAN(d.cfg.MAC)
+}
+
+// Broadcast calls fn concurrently with each of the named TVs, or every TV if no names
+// are given, returning the error for each TV. TVs which can't be connected to return
+// the connection error without calling fn.
+//
+//	errs := m.Broadcast(func(tv *webos.TV) error {
+//		return tv.Notification("Meeting starts in 5 minutes")
+//	}, "lobby", "board-room")
+func (m *Manager) Broadcast(fn func(tv *TV) error, names ...string) map[string]error {
+	if len(names) == 0 {
+		names = m.Names()
+	}
+
+	var mu sync.Mutex
+	errs := make(map[string]error, len(names))
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			tv, err := m.TV(name)
+			if err == nil {
+				err = fn(tv)
+			}
+
+			mu.Lock()
+			errs[name] = err
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// Close closes the connection to every TV, and stops checking the connections. TVs
+// used after Close are connected to again.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+	for _, d := range m.devices {
+		if e := d.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// device returns the named device.
+func (m *Manager) device(name string) (*managedTV, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.devices[name]
+	if !ok {
+		return nil, errors.Errorf("unknown TV: %s", name)
+	}
+	return d, nil
+}
+
+// close closes the connection to the TV, if connected.
+func (d *managedTV) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+
+	if d.tv == nil {
+		return nil
+	}
+
+	err := d.tv.Close()
+	d.tv = nil
+	return err
+}
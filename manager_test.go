@@ -0,0 +1,131 @@
+package webos_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// registrations returns how many times the Server was registered with.
+func registrations(srv *webostest.Server) int {
+	n := 0
+	for _, msg := range srv.Messages() {
+		if msg.Type == webos.RegisterMessageType {
+			n++
+		}
+	}
+	return n
+}
+
+// keptAlive returns a Manager for the Server, as the TV named "tv", checking the
+// connection every 20ms.
+func keptAlive(t *testing.T, srv *webostest.Server) *webos.Manager {
+	t.Helper()
+
+	m := webos.NewManager(map[string]webos.DeviceConfig{
+		"tv": {Address: srv.Host(), ClientKey: webostest.DefaultClientKey},
+	})
+	m.Dialer = srv.Dialer()
+	m.KeepAlive = 20 * time.Millisecond
+	m.Setup = func(_ string, tv *webos.TV) { tv.SetTimeout(50 * time.Millisecond) }
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestManagerKeepAliveReconnects(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	m := keptAlive(t, srv)
+	tv, err := m.TV("tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the connection is lost, and reconnected before the TV is next used
+	tv.Close()
+	eventually(t, func() bool { return registrations(srv) == 2 })
+
+	if reconnected, err := m.TV("tv"); err != nil || reconnected == tv {
+		t.Errorf("TV returned %v, %v, want the reconnected TV", reconnected, err)
+	}
+}
+
+func TestManagerKeepAliveUnresponsive(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	// the TV stops responding on the first connection
+	block := make(chan struct{})
+	defer close(block)
+	var once sync.Once
+	srv.Handle(webos.APIServiceListCommand, func(webos.Message) webos.Message {
+		blocked := false
+		once.Do(func() { blocked = true })
+		if blocked {
+			<-block
+		}
+		return webostest.Response(webos.Payload{"services": []interface{}{}})
+	})
+
+	m := keptAlive(t, srv)
+	if _, err := m.TV("tv"); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, func() bool { return registrations(srv) == 2 })
+}
+
+func TestManagerCloseStopsKeepAlive(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	m := keptAlive(t, srv)
+	tv, err := m.TV("tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tv.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	if n := registrations(srv); n != 1 {
+		t.Errorf("the TV was registered with %d times after the Manager was closed, want 1", n)
+	}
+}
+
+func TestManagerManifest(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	m := webos.NewManager(map[string]webos.DeviceConfig{
+		"tv": {Address: srv.Host(), ClientKey: webostest.DefaultClientKey, Manifest: "basic"},
+	})
+	m.Dialer = srv.Dialer()
+	defer m.Close()
+
+	tv, err := m.TV("tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the basic ManifestPreset doesn't request CONTROL_INPUT_TEXT
+	if err := tv.InsertText("hello", false); !errors.Is(err, webos.ErrPermissionDenied) {
+		t.Errorf("InsertText returned %v, want ErrPermissionDenied", err)
+	}
+	for _, msg := range srv.Messages() {
+		if msg.URI == webos.IMEInsertTextCommand {
+			t.Error("the request was made without the permission")
+		}
+	}
+}
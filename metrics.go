@@ -0,0 +1,52 @@
+package webos
+
+import (
+	"context"
+	"time"
+)
+
+// Metrics receives measurements of the requests made to the TV, e.g. to export them
+// to a monitoring system. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// RequestStarted is called before the request is written to the TV.
+	RequestStarted(uri Command)
+
+	// RequestFinished is called when the response is received, or the request fails,
+	// with the time taken. Timeouts can be tested using errors.Is with ErrTimeout.
+	RequestFinished(uri Command, d time.Duration, err error)
+
+	// Connected is called when the MessageHandler starts reading from the connection.
+	// A Manager reconnecting to a TV sets the Metrics again in its Setup, so Connected
+	// is called once for each connection.
+	Connected()
+
+	// Disconnected is called when the connection to the TV is closed or lost, with the
+	// error returned by the MessageHandler.
+	Disconnected(err error)
+}
+
+// SetMetrics sets the Metrics receiving measurements of the requests made to the TV,
+// and of its connection. It must be called before the MessageHandler is started.
+// Registration requests are reported with the Command "register".
+func (tv *TV) SetMetrics(m Metrics) {
+	tv.metrics = m
+	tv.handlers.Store(nil)
+}
+
+// measure wraps the Handler, reporting requests to the Metrics.
+func (tv *TV) measure(next Handler) Handler {
+	return func(ctx context.Context, msg *Message) (Message, error) {
+		uri := msg.URI
+		if msg.Type == RegisterMessageType {
+			uri = Command(RegisterMessageType)
+		}
+
+		tv.metrics.RequestStarted(uri)
+		start := time.Now()
+
+		res, err := next(ctx, msg)
+
+		tv.metrics.RequestFinished(uri, time.Since(start), err)
+		return res, err
+	}
+}
@@ -0,0 +1,107 @@
+package webos_test
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// recordedMetrics records the calls to its Metrics methods.
+type recordedMetrics struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (m *recordedMetrics) record(call string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+func (m *recordedMetrics) recorded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+func (m *recordedMetrics) RequestStarted(uri webos.Command) { m.record("started " + string(uri)) }
+
+func (m *recordedMetrics) RequestFinished(uri webos.Command, _ time.Duration, _ error) {
+	m.record("finished " + string(uri))
+}
+
+func (m *recordedMetrics) Connected() { m.record("connected") }
+
+func (m *recordedMetrics) Disconnected(error) { m.record("disconnected") }
+
+func TestSetMetrics(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv, err := webos.Dial(srv.Host(), webos.WithDialer(srv.Dialer()))
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer tv.Close()
+
+	metrics := &recordedMetrics{}
+	tv.SetMetrics(metrics)
+	if calls := metrics.recorded(); len(calls) != 0 {
+		t.Fatalf("SetMetrics reported %v, want nothing until the MessageHandler starts", calls)
+	}
+
+	go tv.MessageHandler()
+	eventually(t, func() bool { return reflect.DeepEqual(metrics.recorded(), []string{"connected"}) })
+}
+
+func TestMetricsConnections(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	metrics := &recordedMetrics{}
+	m := webos.NewManager(map[string]webos.DeviceConfig{
+		"tv": {Address: srv.Host(), ClientKey: webostest.DefaultClientKey},
+	})
+	m.Dialer = srv.Dialer()
+	m.Setup = func(_ string, tv *webos.TV) { tv.SetMetrics(metrics) }
+	defer m.Close()
+
+	tv, err := m.TV("tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tv.Close()
+	eventually(t, func() bool {
+		calls := metrics.recorded()
+		return len(calls) > 0 && calls[len(calls)-1] == "disconnected"
+	})
+
+	// the Manager reconnects
+	if _, err := m.TV("tv"); err != nil {
+		t.Fatal(err)
+	}
+
+	var connections []string
+	for _, call := range metrics.recorded() {
+		if call == "connected" || call == "disconnected" {
+			connections = append(connections, call)
+		}
+	}
+	if want := []string{"connected", "disconnected", "connected"}; !reflect.DeepEqual(connections, want) {
+		t.Errorf("connections are %v, want %v", connections, want)
+	}
+	for _, call := range metrics.recorded() {
+		if strings.HasPrefix(call, "started ") {
+			if call != "started register" {
+				t.Errorf("first request is %q, want the registration", call)
+			}
+			break
+		}
+	}
+}
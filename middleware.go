@@ -0,0 +1,148 @@
+package webos
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Handler sends a Message to the TV and returns its validated response.
+type Handler func(ctx context.Context, msg *Message) (Message, error)
+
+// Middleware wraps a Handler, e.g. to log, retry or alter requests. Middleware can
+// return a response without calling next, in which case nothing is written to the TV.
+type Middleware func(next Handler) Handler
+
+// Use registers Middleware wrapping the requests made to the TV by Command and the
+// command methods, registration, the pointer input socket, and subscribing and
+// unsubscribing, including by Watch. The first Middleware registered is the outermost.
+// Only the first response to a subscription is passed to Middleware.
+//
+// Middleware should be registered before the TV is used.
+func (tv *TV) Use(mw ...Middleware) {
+	tv.middleware = append(tv.middleware, mw...)
+	tv.handlers.Store(nil)
+}
+
+// handler returns the Handler which writes requests to the TV, wrapped by the
+// Metrics and the registered Middleware. The Handler is built once, and again after
+// the Middleware or Metrics change.
+func (tv *TV) handler() Handler {
+	if h := tv.handlers.Load(); h != nil {
+		return *h
+	}
+
+	h := tv.buildHandler()
+	tv.handlers.Store(&h)
+	return h
+}
+
+// buildHandler wraps the Handler which writes requests to the TV.
+func (tv *TV) buildHandler() Handler {
+	h := Handler(tv.roundTrip)
+	if tv.metrics != nil {
+		h = tv.measure(h)
+	}
+
+	for i := len(tv.middleware) - 1; i >= 0; i-- {
+		h = tv.middleware[i](h)
+	}
+	return h
+}
+
+// LoggingMiddleware logs each request's type, ID, URI, duration and error to the logger.
+// Failed requests are logged at the error level, others at the debug level.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (Message, error) {
+			start := time.Now()
+			res, err := next(ctx, msg)
+
+			attrs := []slog.Attr{
+				slog.String("type", string(msg.Type)),
+				slog.String("id", msg.ID),
+				slog.String("uri", string(msg.URI)),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+				logger.LogAttrs(ctx, slog.LevelError, "request failed", attrs...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelDebug, "request", attrs...)
+			}
+
+			return res, err
+		}
+	}
+}
+
+// RetryMiddleware retries requests which time out, up to attempts times in total,
+// if they are idempotent reads, i.e. the Command's method starts with "get" or "list".
+// Other requests aren't retried as the TV may have acted on them. Each attempt has a
+// new Message ID, e.g. `3f9a0c1e-17.2`, so a late response to an earlier attempt isn't
+// taken for the response to the retry.
+func RetryMiddleware(attempts int) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (Message, error) {
+			res, err := next(ctx, msg)
+			if !isRead(msg) {
+				return res, err
+			}
+
+			for i := 1; i < attempts && errors.Is(err, ErrTimeout) && ctx.Err() == nil; i++ {
+				retry := *msg
+				retry.ID = msg.ID + "." + strconv.Itoa(i+1)
+				res, err = next(ctx, &retry)
+			}
+			return res, err
+		}
+	}
+}
+
+// isRead returns true if the Message is a request for a Command which only reads
+// state, e.g. `ssap://audio/getVolume`.
+func isRead(msg *Message) bool {
+	if msg.Type != RequestMessageType {
+		return false
+	}
+
+	uri := string(msg.URI)
+	method := strings.ToLower(uri[strings.LastIndex(uri, "/")+1:])
+	return strings.HasPrefix(method, "get") || strings.HasPrefix(method, "list")
+}
+
+// DryRunMiddleware returns a successful response to every request without writing it
+// to the TV, e.g. to test automations without affecting the TV. Registration returns
+// the client key given in the request, Subscriptions receive no changes, and the
+// pointer input socket discards the buttons and moves sent, logging them at the debug
+// level.
+func DryRunMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (Message, error) {
+			switch {
+			case msg.Type == RegisterMessageType:
+				p := Payload{}
+				if key, ok := msg.Payload["client-key"]; ok {
+					p["client-key"] = key
+				}
+				return Message{Type: RegisteredMessageType, ID: msg.ID, Payload: p}, nil
+			case msg.URI == GetPointerInputSocketCommand:
+				return Message{
+					Type:    ResponseMessageType,
+					ID:      msg.ID,
+					Payload: Payload{"returnValue": true, "socketPath": dryRunSocket},
+				}, nil
+			}
+
+			return Message{
+				Type:    ResponseMessageType,
+				ID:      msg.ID,
+				Payload: Payload{"returnValue": true},
+			}, nil
+		}
+	}
+}
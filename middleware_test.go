@@ -0,0 +1,124 @@
+package webos_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// recordTypes returns Middleware recording the type of each Message.
+func recordTypes(types *[]webos.MessageType, mu *sync.Mutex) webos.Middleware {
+	return func(next webos.Handler) webos.Handler {
+		return func(ctx context.Context, msg *webos.Message) (webos.Message, error) {
+			mu.Lock()
+			*types = append(*types, msg.Type)
+			mu.Unlock()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// dialUsing dials the Server and authorises, with the Middleware registered.
+func dialUsing(t *testing.T, srv *webostest.Server, mw ...webos.Middleware) *webos.TV {
+	t.Helper()
+
+	tv := dial(t, srv)
+	tv.Use(mw...)
+	if err := tv.AuthoriseClientKey(webostest.DefaultClientKey); err != nil {
+		t.Fatalf("could not authorise: %v", err)
+	}
+	return tv
+}
+
+func TestSubscribeMiddleware(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	var mu sync.Mutex
+	var types []webos.MessageType
+	tv := dialUsing(t, srv, recordTypes(&types, &mu))
+
+	w, err := tv.Watch(webos.PowerEvent)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	<-w.C
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []webos.MessageType{webos.RegisterMessageType, webos.SubscribeMessageType, webos.UnsubscribeMessageType}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("Middleware received %v, want %v", types, want)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv := dialUsing(t, srv, webos.DryRunMiddleware())
+
+	if err := tv.SetVolume(10); err != nil {
+		t.Fatalf("SetVolume: %v", err)
+	}
+	if err := tv.KeyHome(); err != nil {
+		t.Fatalf("KeyHome: %v", err)
+	}
+
+	sub, err := tv.Subscribe(webos.AudioGetVolumeCommand, nil)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	<-sub.C
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range sub.C {
+	}
+
+	if msgs := srv.Messages(); len(msgs) != 0 {
+		t.Errorf("the TV received %v in a dry run", msgs)
+	}
+	if buttons := srv.Buttons(); len(buttons) != 0 {
+		t.Errorf("the TV received buttons %v in a dry run", buttons)
+	}
+}
+
+func TestRetryMiddlewareIDs(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	// the first attempt times out without being written to the TV
+	var ids []string
+	timeout := func(next webos.Handler) webos.Handler {
+		return func(ctx context.Context, msg *webos.Message) (webos.Message, error) {
+			if msg.Type != webos.RequestMessageType || msg.URI != webos.AudioGetVolumeCommand {
+				return next(ctx, msg)
+			}
+
+			ids = append(ids, msg.ID)
+			if len(ids) == 1 {
+				return webos.Message{}, fmt.Errorf("%s: %w", msg.URI, webos.ErrTimeout)
+			}
+			return next(ctx, msg)
+		}
+	}
+	tv := dialUsing(t, srv, webos.RetryMiddleware(2), timeout)
+
+	if _, err := tv.GetVolume(); err != nil {
+		t.Fatalf("GetVolume: %v", err)
+	}
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Errorf("attempts have the IDs %v, want two different IDs", ids)
+	}
+}
@@ -0,0 +1,110 @@
+package mqttbridge
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// entity is a Home Assistant entity published by MQTT discovery.
+type entity struct {
+	component string
+	object    string
+	name      string
+	command   bool
+	config    map[string]interface{}
+}
+
+// entities are the Home Assistant entities for the TV state.
+var entities = []entity{
+	{component: "switch", object: "power", name: "Power", command: true, config: map[string]interface{}{
+		"payload_on":  "on",
+		"payload_off": "off",
+		"icon":        "mdi:television",
+	}},
+	{component: "number", object: "volume", name: "Volume", command: true, config: map[string]interface{}{
+		"min":  0,
+		"max":  100,
+		"icon": "mdi:volume-high",
+	}},
+	{component: "switch", object: "mute", name: "Mute", command: true, config: map[string]interface{}{
+		"payload_on":  "on",
+		"payload_off": "off",
+		"icon":        "mdi:volume-off",
+	}},
+	{component: "sensor", object: "app", name: "App", config: map[string]interface{}{
+		"icon": "mdi:application",
+	}},
+	{component: "sensor", object: "input", name: "Input", config: map[string]interface{}{
+		"icon": "mdi:video-input-hdmi",
+	}},
+	{component: "sensor", object: "channel", name: "Channel", config: map[string]interface{}{
+		"icon": "mdi:television-classic",
+	}},
+}
+
+// discoveryTopic returns the Home Assistant discovery topic of the entity, e.g.
+// `homeassistant/switch/webos_living-room/power/config`.
+func (c Config) discoveryTopic(e entity) string {
+	prefix := c.DiscoveryPrefix
+	if prefix == "" {
+		prefix = "homeassistant"
+	}
+	return strings.Join([]string{prefix, e.component, c.nodeID(), e.object, "config"}, "/")
+}
+
+// nodeID returns the Home Assistant node ID of the TV.
+func (c Config) nodeID() string {
+	return "webos_" + c.Name
+}
+
+// publishDiscovery publishes the retained Home Assistant discovery payload of each
+// entity.
+func (b *Bridge) publishDiscovery(tv *webos.TV) error {
+	device := map[string]interface{}{
+		"identifiers":  []string{b.cfg.nodeID()},
+		"name":         b.cfg.Name,
+		"manufacturer": "LG",
+	}
+	// the model is only described if the Capabilities can be detected
+	caps := tv.Capabilities()
+	if caps == nil {
+		var err error
+		if caps, err = tv.DetectCapabilities(context.Background()); err != nil {
+			b.logf("could not detect the capabilities of %s: %v", b.cfg.Name, err)
+		}
+	}
+	if caps != nil {
+		if caps.SystemInfo.ModelName != "" {
+			device["model"] = caps.SystemInfo.ModelName
+		}
+		if caps.Software.ProductName != "" {
+			device["sw_version"] = caps.Software.ProductName
+		}
+	}
+
+	for _, e := range entities {
+		config := map[string]interface{}{
+			"name":               e.name,
+			"unique_id":          b.cfg.nodeID() + "_" + e.object,
+			"state_topic":        b.cfg.topic("state", e.object),
+			"availability_topic": b.cfg.AvailabilityTopic(),
+			"device":             device,
+		}
+		if e.command {
+			config["command_topic"] = b.cfg.topic("set", e.object)
+		}
+		for k, v := range e.config {
+			config[k] = v
+		}
+
+		if err := b.publish(b.cfg.discoveryTopic(e), config); err != nil {
+			return errors.Wrap(err, e.object)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,496 @@
+// Package mqttbridge bridges TVs to MQTT for home automation.
+//
+// TV state is published to retained topics and commands are read from the set topics:
+//
+//	webos/<name>/state/power    on or off
+//	webos/<name>/state/volume   0-100
+//	webos/<name>/state/mute     on or off
+//	webos/<name>/state/app      foreground app ID
+//	webos/<name>/state/input    external input ID, empty unless an input is shown
+//	webos/<name>/state/channel  channel number
+//
+//	webos/<name>/set/power      on or off, on uses Wake-on-LAN if the TV is off
+//	webos/<name>/set/volume     0-100
+//	webos/<name>/set/mute       on or off
+//	webos/<name>/set/launch     app ID or alias
+//	webos/<name>/set/input      external input ID or alias
+//	webos/<name>/set/button     button name, one of webos.Buttons, e.g. up, ok or play
+//
+// Home Assistant MQTT discovery payloads are published so the TV is added as a device.
+//
+// Bridges returned by NewManaged reconnect to the TV once the connection is lost, e.g.
+// as the TV was turned off, publishing the TV as offline until then.
+package mqttbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// Config configures a Bridge.
+type Config struct {
+	// Name identifies the TV in topics, e.g. `webos/<name>/state/volume`.
+	Name string
+
+	// Prefix is the topic prefix. It defaults to "webos".
+	Prefix string
+
+	// DiscoveryPrefix is the Home Assistant discovery prefix. It defaults to
+	// "homeassistant".
+	DiscoveryPrefix string
+
+	// DisableDiscovery disables publishing Home Assistant discovery payloads.
+	DisableDiscovery bool
+
+	// Apps and Inputs are aliases of app and external input IDs accepted by the
+	// launch and input set topics, e.g. "youtube: youtube.leanback.v4".
+	Apps   map[string]string
+	Inputs map[string]string
+
+	// MAC is the MAC address of the TV, used to turn it on with Wake-on-LAN. Bridges
+	// returned by NewManaged use the Manager's MAC address of the TV if it's empty.
+	MAC string
+
+	// Retry is how long to wait before reconnecting to the TV once the connection is
+	// lost. It defaults to DefaultRetry.
+	Retry time.Duration
+
+	// QoS is the quality of service used to publish and subscribe.
+	QoS byte
+
+	// ErrorLog logs errors executing commands. The log package's standard logger is
+	// used if nil.
+	ErrorLog *log.Logger
+}
+
+// DefaultRetry is how long to wait before reconnecting to the TV, unless set by
+// Config.Retry.
+const DefaultRetry = 10 * time.Second
+
+// topic returns the topic for the TV, e.g. `webos/living-room/state/volume`.
+func (c Config) topic(parts ...string) string {
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = "webos"
+	}
+	return strings.Join(append([]string{prefix, c.Name}, parts...), "/")
+}
+
+// AvailabilityTopic returns the topic the Bridge publishes "online" to when started and
+// "offline" to when closed. It should be used as the client's will so the TV is shown
+// as unavailable if the Bridge disconnects:
+//
+//	opts.SetWill(cfg.AvailabilityTopic(), "offline", 1, true)
+func (c Config) AvailabilityTopic() string {
+	return c.topic("availability")
+}
+
+// Bridge publishes a TV's state to MQTT and executes the commands it receives.
+type Bridge struct {
+	client mqtt.Client
+	cfg    Config
+
+	// connect returns the TV, and manager is set if it reconnects.
+	connect func() (*webos.TV, error)
+	manager *webos.Manager
+
+	commands chan mqtt.Message
+
+	mu      sync.Mutex
+	state   map[string]string
+	tv      *webos.TV
+	watcher *webos.Watcher
+	inputs  []webos.ExternalInput
+	inputsC *webos.Subscription
+
+	done chan struct{}
+	once sync.Once
+}
+
+// New returns a Bridge between the TV and the connected MQTT client. The TV must be
+// connected, with the MessageHandler running, and authorised. The Bridge stops
+// publishing state once the connection to the TV is lost, use NewManaged to reconnect.
+func New(tv *webos.TV, client mqtt.Client, cfg Config) *Bridge {
+	b := newBridge(client, cfg)
+	b.connect = func() (*webos.TV, error) { return tv, nil }
+	return b
+}
+
+// NewManaged returns a Bridge between the TV of the Manager named by Config.Name and the
+// connected MQTT client. The TV is connected when the Bridge is started, or once it's
+// turned on, and reconnected once the connection is lost.
+func NewManaged(m *webos.Manager, client mqtt.Client, cfg Config) *Bridge {
+	b := newBridge(client, cfg)
+	b.connect = func() (*webos.TV, error) { return m.TV(cfg.Name) }
+	b.manager = m
+	return b
+}
+
+// newBridge returns a Bridge without a TV.
+func newBridge(client mqtt.Client, cfg Config) *Bridge {
+	return &Bridge{
+		client:   client,
+		cfg:      cfg,
+		commands: make(chan mqtt.Message, 16),
+		state:    make(map[string]string),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start publishes the discovery payloads, subscribes to the set topics and starts
+// publishing state changes. Bridges returned by NewManaged start when the TV can't be
+// connected to, e.g. as it's off, and connect to it once it's turned on.
+func (b *Bridge) Start() error {
+	if b.cfg.Name == "" {
+		return errors.New("a name is required")
+	}
+
+	w, err := b.watch()
+	if err != nil && b.manager == nil {
+		return err
+	}
+
+	token := b.client.Subscribe(b.cfg.topic("set", "+"), b.cfg.QoS, func(_ mqtt.Client, msg mqtt.Message) {
+		select {
+		case b.commands <- msg:
+		case <-b.done:
+		}
+	})
+	if err := wait(token); err != nil {
+		b.disconnect()
+		return errors.Wrap(err, "could not subscribe to commands")
+	}
+
+	if w == nil {
+		b.logf("could not connect to %s, retrying: %v", b.cfg.Name, err)
+		b.offline()
+	}
+
+	go b.run(w)
+	go b.execute()
+
+	return nil
+}
+
+// Close unsubscribes from the set topics, stops publishing state changes and
+// publishes the TV as offline.
+func (b *Bridge) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.done)
+		b.disconnect()
+
+		if e := wait(b.client.Unsubscribe(b.cfg.topic("set", "+"))); e != nil {
+			err = e
+		}
+
+		if e := b.publish(b.cfg.AvailabilityTopic(), "offline"); e != nil && err == nil {
+			err = e
+		}
+	})
+	return err
+}
+
+// watch connects to the TV, publishes the discovery payloads and the TV as online,
+// and returns a Watcher of its state. The external inputs are subscribed to, so the
+// input shown by the foreground app is found.
+func (b *Bridge) watch() (*webos.Watcher, error) {
+	tv, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	// the TV may not have any inputs
+	inputs, _ := tv.Subscribe(webos.TVExternalInputListCommand, nil)
+
+	if !b.cfg.DisableDiscovery {
+		if err := b.publishDiscovery(tv); err != nil {
+			b.closeInputs(inputs)
+			return nil, errors.Wrap(err, "could not publish discovery payloads")
+		}
+	}
+
+	w, err := tv.Watch(webos.VolumeEvent, webos.AppEvent, webos.PowerEvent, webos.ChannelEvent)
+	if err != nil {
+		b.closeInputs(inputs)
+		return nil, errors.Wrap(err, "could not watch the TV")
+	}
+
+	b.mu.Lock()
+	select {
+	case <-b.done:
+		// the Bridge was closed while connecting
+		b.mu.Unlock()
+		w.Close()
+		b.closeInputs(inputs)
+		return nil, errors.New("the bridge is closed")
+	default:
+	}
+	b.tv, b.watcher, b.inputsC = tv, w, inputs
+	b.mu.Unlock()
+
+	if inputs != nil {
+		go b.watchInputs(inputs)
+	}
+
+	if err := b.publish(b.cfg.AvailabilityTopic(), "online"); err != nil {
+		b.disconnect()
+		return nil, errors.Wrap(err, "could not publish availability")
+	}
+	return w, nil
+}
+
+// run publishes the state in the Watcher's Events until it is closed, then reconnects
+// if the Bridge reconnects, until the Bridge is closed.
+func (b *Bridge) run(w *webos.Watcher) {
+	for {
+		if w != nil {
+			b.publishEvents(w)
+			b.disconnect()
+
+			select {
+			case <-b.done:
+				return
+			default:
+			}
+
+			// the connection to the TV is lost
+			b.offline()
+		}
+
+		if b.manager == nil {
+			return
+		}
+
+		retry := b.cfg.Retry
+		if retry <= 0 {
+			retry = DefaultRetry
+		}
+
+		select {
+		case <-time.After(retry):
+		case <-b.done:
+			return
+		}
+
+		// the TV is expected to be unreachable while it's off
+		w, _ = b.watch()
+	}
+}
+
+// publishEvents publishes the state in the Watcher's Events until it is closed.
+func (b *Bridge) publishEvents(w *webos.Watcher) {
+	for e := range w.C {
+		switch data := e.Data.(type) {
+		case *webos.Volume:
+			b.publishState("volume", strconv.Itoa(int(data.Volume)))
+			b.publishState("mute", webos.OnOff(data.Muted))
+		case *webos.App:
+			b.publishState("app", data.AppID)
+			b.publishState("input", b.input(data.AppID))
+		case *webos.PowerState:
+			b.publishState("power", webos.OnOff(data.State == "Active"))
+		case webos.Payload:
+			if n, ok := data["channelNumber"]; ok && e.Type == webos.ChannelEvent {
+				b.publishState("channel", fmt.Sprint(n))
+			}
+		}
+	}
+}
+
+// watchInputs keeps the external inputs up to date, e.g. as inputs are renamed or
+// devices are connected, until the Subscription is closed.
+func (b *Bridge) watchInputs(sub *webos.Subscription) {
+	for msg := range sub.C {
+		var list webos.ExternalInputList
+		if err := sub.Decode(msg, &list); err != nil {
+			continue
+		}
+
+		b.mu.Lock()
+		b.inputs = list.Devices
+		b.mu.Unlock()
+	}
+}
+
+// offline publishes the TV as off and offline.
+func (b *Bridge) offline() {
+	b.publishState("power", "off")
+	b.publish(b.cfg.AvailabilityTopic(), "offline")
+}
+
+// disconnect stops watching the TV.
+func (b *Bridge) disconnect() {
+	b.mu.Lock()
+	w, inputs := b.watcher, b.inputsC
+	b.tv, b.watcher, b.inputsC, b.inputs = nil, nil, nil, nil
+	b.mu.Unlock()
+
+	if w != nil {
+		w.Close()
+	}
+	b.closeInputs(inputs)
+}
+
+// closeInputs closes the external inputs Subscription, if any.
+func (b *Bridge) closeInputs(sub *webos.Subscription) {
+	if sub != nil {
+		sub.Close()
+	}
+}
+
+// input returns the ID of the external input shown by the app, or an empty string.
+func (b *Bridge) input(app string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, in := range b.inputs {
+		if in.AppID == app {
+			return in.ID
+		}
+	}
+	return ""
+}
+
+// publishState publishes the state if it has changed.
+func (b *Bridge) publishState(name, value string) {
+	b.mu.Lock()
+	if v, ok := b.state[name]; ok && v == value {
+		b.mu.Unlock()
+		return
+	}
+	b.state[name] = value
+	b.mu.Unlock()
+
+	if err := b.publish(b.cfg.topic("state", name), value); err != nil {
+		b.logf("could not publish %s state: %v", name, err)
+	}
+}
+
+// execute executes the received commands in order until the Bridge is closed.
+func (b *Bridge) execute() {
+	for {
+		select {
+		case msg := <-b.commands:
+			cmd := msg.Topic()[strings.LastIndex(msg.Topic(), "/")+1:]
+			if err := b.command(cmd, strings.TrimSpace(string(msg.Payload()))); err != nil {
+				b.logf("could not execute %s command: %v", cmd, err)
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// command executes the named command with the value.
+func (b *Bridge) command(cmd, value string) error {
+	if cmd == "power" {
+		on, err := webos.ParseOnOff(value)
+		if err != nil {
+			return err
+		}
+		if on {
+			return b.powerOn()
+		}
+	}
+
+	tv, err := b.connect()
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "power":
+		return tv.Shutdown()
+	case "volume":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Errorf("invalid volume: %s", value)
+		}
+		return tv.SetVolume(v)
+	case "mute":
+		on, err := webos.ParseOnOff(value)
+		if err != nil {
+			return err
+		}
+		if on {
+			return tv.Mute()
+		}
+		return tv.Unmute()
+	case "launch":
+		return tv.LaunchApp(alias(b.cfg.Apps, value))
+	case "input":
+		return tv.SwitchInput(alias(b.cfg.Inputs, value))
+	case "button":
+		return tv.PressButton(value)
+	default:
+		return errors.Errorf("unknown command: %s", cmd)
+	}
+}
+
+// powerOn turns the screen on if the TV is connected, e.g. after turning the screen
+// off, and otherwise turns the TV on with Wake-on-LAN.
+func (b *Bridge) powerOn() error {
+	b.mu.Lock()
+	tv, power := b.tv, b.state["power"]
+	b.mu.Unlock()
+
+	switch {
+	case tv != nil && power == "on":
+		return nil
+	case tv != nil:
+		return tv.ScreenOn()
+	case b.cfg.MAC != "":
+		return webos.WakeOnLAN(b.cfg.MAC)
+	case b.manager != nil:
+		return b.manager.Wake(b.cfg.Name)
+	default:
+		return errors.New("the TV is off and there's no MAC address to turn it on with Wake-on-LAN")
+	}
+}
+
+// alias returns the ID of the alias, or the value if it isn't an alias.
+func alias(aliases map[string]string, value string) string {
+	if id, ok := aliases[value]; ok {
+		return id
+	}
+	return value
+}
+
+// publish publishes the retained payload, encoding it as JSON unless it is a string.
+func (b *Bridge) publish(topic string, payload interface{}) error {
+	if _, ok := payload.(string); !ok {
+		p, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		payload = p
+	}
+
+	return wait(b.client.Publish(topic, b.cfg.QoS, true, payload))
+}
+
+// logf logs an error.
+func (b *Bridge) logf(format string, args ...interface{}) {
+	if b.cfg.ErrorLog != nil {
+		b.cfg.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// wait waits for the token to complete and returns its error.
+func wait(token mqtt.Token) error {
+	token.Wait()
+	return token.Error()
+}
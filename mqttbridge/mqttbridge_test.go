@@ -0,0 +1,259 @@
+package mqttbridge_test
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/mqttbridge"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// broker is an embedded MQTT broker, recording the payloads published to each topic.
+type broker struct {
+	addr string
+
+	mu     sync.Mutex
+	topics map[string][]string
+}
+
+// newBroker starts a broker listening on a random port.
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	l := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	if err := server.AddListener(l); err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+
+	b := &broker{addr: "tcp://" + l.Address(), topics: make(map[string][]string)}
+	err := server.Subscribe("#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.topics[pk.TopicName] = append(b.topics[pk.TopicName], string(pk.Payload))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// published returns the payloads published to the topic.
+func (b *broker) published(topic string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.topics[topic]...)
+}
+
+// last returns the last payload published to the topic.
+func (b *broker) last(topic string) string {
+	p := b.published(topic)
+	if len(p) == 0 {
+		return ""
+	}
+	return p[len(p)-1]
+}
+
+// client returns an MQTT client connected to the broker.
+func (b *broker) client(t *testing.T) mqtt.Client {
+	t.Helper()
+
+	c := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(b.addr))
+	if token := c.Connect(); token.Wait() && token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+	t.Cleanup(func() { c.Disconnect(0) })
+	return c
+}
+
+// eventually calls f until it returns true, failing the test if it doesn't within a second.
+func eventually(t *testing.T, f func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); !f(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+	}
+}
+
+// start starts a Bridge to the Server's TV, named "tv", through a Manager.
+func start(t *testing.T, srv *webostest.Server, b *broker) (*mqttbridge.Bridge, *webos.Manager) {
+	t.Helper()
+
+	m := webos.NewManager(map[string]webos.DeviceConfig{
+		"tv": {Address: srv.Host(), ClientKey: webostest.DefaultClientKey},
+	})
+	m.Dialer = srv.Dialer()
+	t.Cleanup(func() { m.Close() })
+
+	bridge := mqttbridge.NewManaged(m, b.client(t), mqttbridge.Config{
+		Name:     "tv",
+		Retry:    20 * time.Millisecond,
+		QoS:      1,
+		ErrorLog: log.New(io.Discard, "", 0),
+	})
+	if err := bridge.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { bridge.Close() })
+	return bridge, m
+}
+
+// requested returns whether the Server received a request for the command.
+func requested(srv *webostest.Server, uri webos.Command, match func(webos.Payload) bool) bool {
+	for _, msg := range srv.Messages() {
+		if msg.URI == uri && (match == nil || match(msg.Payload)) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBridgeState(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 12, "muted": true})
+
+	b := newBroker(t)
+	start(t, srv, b)
+
+	want := map[string]string{
+		"webos/tv/availability": "online",
+		"webos/tv/state/power":  "on",
+		"webos/tv/state/volume": "12",
+		"webos/tv/state/mute":   "on",
+		"webos/tv/state/app":    "com.webos.app.livetv",
+	}
+	eventually(t, func() bool {
+		for topic, v := range want {
+			if b.last(topic) != v {
+				return false
+			}
+		}
+		return true
+	})
+
+	if b.last("homeassistant/switch/webos_tv/power/config") == "" {
+		t.Error("no discovery payload published for the power switch")
+	}
+}
+
+func TestBridgeCommand(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	b := newBroker(t)
+	start(t, srv, b)
+
+	c := b.client(t)
+	if token := c.Publish("webos/tv/set/volume", 1, false, "20"); token.Wait() && token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+
+	eventually(t, func() bool {
+		return requested(srv, webos.AudioSetVolumeCommand, func(p webos.Payload) bool { return p["volume"] == float64(20) })
+	})
+}
+
+func TestBridgePowerOn(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+	srv.Respond(webos.SoftwareInfoCommand, webos.Payload{"product_name": "webOSTV 5.0", "model_name": "HE_DTV_W20H_AFADABAA", "major_ver": "03", "minor_ver": "21.30"})
+	srv.Respond(webos.PowerStateCommand, webos.Payload{"state": "Screen Off"})
+
+	b := newBroker(t)
+	start(t, srv, b)
+	eventually(t, func() bool { return b.last("webos/tv/state/power") == "off" })
+
+	c := b.client(t)
+	if token := c.Publish("webos/tv/set/power", 1, false, "ON"); token.Wait() && token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+
+	// the TV is connected, so the screen is turned back on
+	eventually(t, func() bool { return requested(srv, webos.ScreenOnCommand, nil) })
+}
+
+func TestBridgeReconnects(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	b := newBroker(t)
+	_, m := start(t, srv, b)
+	eventually(t, func() bool { return b.last("webos/tv/availability") == "online" })
+
+	// the connection is lost, e.g. as the TV was turned off
+	tv, err := m.TV("tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tv.Close()
+
+	eventually(t, func() bool {
+		p := b.published("webos/tv/availability")
+		return len(p) >= 3 && p[len(p)-2] == "offline" && p[len(p)-1] == "online"
+	})
+	if reconnected, _ := m.TV("tv"); reconnected == tv {
+		t.Error("the TV wasn't reconnected")
+	}
+}
+
+func TestBridgeInputs(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	b := newBroker(t)
+	start(t, srv, b)
+	eventually(t, func() bool { return b.last("webos/tv/availability") == "online" })
+
+	// a device is connected after the Bridge started
+	srv.Publish(webos.TVExternalInputListCommand, webos.Payload{"devices": []interface{}{
+		map[string]interface{}{"id": "HDMI_3", "label": "Console", "appId": "com.webos.app.hdmi3", "connected": true},
+	}})
+
+	eventually(t, func() bool {
+		srv.Publish(webos.ApplicationManagerForegroundAppCommand, webos.Payload{"appId": "com.webos.app.hdmi3"})
+		return b.last("webos/tv/state/input") == "HDMI_3"
+	})
+}
+
+func TestBridgeClose(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	b := newBroker(t)
+	bridge, _ := start(t, srv, b)
+	eventually(t, func() bool { return b.last("webos/tv/availability") == "online" })
+
+	if err := bridge.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	eventually(t, func() bool { return b.last("webos/tv/availability") == "offline" })
+}
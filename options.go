@@ -0,0 +1,237 @@
+package webos
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// securePort is the port of the TV's wss socket.
+	securePort = 3001
+
+	// insecurePort is the port of the TV's ws socket, the only socket of older TVs.
+	insecurePort = 3000
+)
+
+// options are the settings of a connection, set by Options.
+type options struct {
+	port     int
+	insecure bool
+	tls      *tls.Config
+	timeout  time.Duration
+	logger   slog.Handler
+	dialer   *websocket.Dialer
+	pin      string
+
+	noFallback bool
+}
+
+// Option configures a connection made by Dial.
+type Option func(*options)
+
+// WithPort sets the port to connect to. By default port 3001 is used, falling back to
+// port 3000 as with WithInsecureWS if the TV refuses the connection.
+func WithPort(port int) Option {
+	return func(o *options) {
+		o.port = port
+	}
+}
+
+// WithInsecureWS connects using ws rather than wss, on port 3000 unless set by WithPort,
+// as used by older TVs.
+func WithInsecureWS() Option {
+	return func(o *options) {
+		o.insecure = true
+	}
+}
+
+// WithoutFallback stops Dial falling back to ws when the TV refuses the wss
+// connection, e.g. while the TV's certificate isn't pinned yet, so a connection which
+// would be pinned on first use can't be downgraded to ws by blocking port 3001.
+func WithoutFallback() Option {
+	return func(o *options) {
+		o.noFallback = true
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of wss connections. By default the TV's
+// self-signed certificate isn't verified.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(o *options) {
+		o.tls = c
+	}
+}
+
+// WithTimeout sets how long to wait to connect to the TV, and for the TV to respond to
+// each request, see SetTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithLogger sets the handler the SSAP protocol is logged to, see SetLogger.
+func WithLogger(h slog.Handler) Option {
+	return func(o *options) {
+		o.logger = h
+	}
+}
+
+// WithDialer sets the Dialer used to connect, e.g. to use a proxy or the webostest
+// Server's Dialer. Its timeouts are used rather than WithTimeout's, and its TLS
+// configuration is replaced if WithTLSConfig is used.
+func WithDialer(d *websocket.Dialer) Option {
+	return func(o *options) {
+		o.dialer = d
+	}
+}
+
+// Dial connects to the TV at host, the IP address or host name of the TV, and returns a
+// pointer to a new TV. The MessageHandler must be started before the TV is used.
+//
+// Unless WithPort, WithInsecureWS, WithCertificatePin or WithoutFallback is used, Dial
+// connects using wss on port 3001 and falls back to ws on port 3000 if the TV refuses
+// the connection, as older TVs don't listen on port 3001.
+func Dial(host string, opts ...Option) (*TV, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.pin != "" && o.insecure {
+		return nil, errors.New("certificate pin can't be verified using ws")
+	}
+
+	dialer := o.newDialer()
+
+	// the fingerprint of the certificate is recorded by the TLS handshake
+	var fingerprint string
+	base := dialer.TLSClientConfig
+	dialer.TLSClientConfig = pinCertificate(base, o.pin, &fingerprint)
+
+	ws, err := o.dial(dialer, host)
+	if err != nil && o.fallback() && errors.Is(err, syscall.ECONNREFUSED) {
+		o.insecure = true
+		ws, err = o.dial(dialer, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tv := &TV{ws: ws, fingerprint: fingerprint}
+	tv.SetTimeout(o.timeout)
+	if fingerprint != "" {
+		tv.inputTLS = pinCertificate(base, fingerprint, nil)
+	}
+	if o.logger != nil {
+		tv.SetLogger(o.logger)
+	}
+
+	attrs := []slog.Attr{slog.String("host", host), slog.Bool("tls", !o.insecure)}
+	if fingerprint != "" {
+		attrs = append(attrs, slog.String("fingerprint", fingerprint))
+	}
+	tv.log(slog.LevelInfo, "connected", attrs...)
+	return tv, nil
+}
+
+// fallback returns true if Dial falls back to ws when the TV refuses the connection.
+func (o *options) fallback() bool {
+	return o.port == 0 && !o.insecure && o.pin == "" && !o.noFallback
+}
+
+// newDialer returns the Dialer configured by the options.
+func (o *options) newDialer() *websocket.Dialer {
+	timeout := o.timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	var d websocket.Dialer
+	if o.dialer != nil {
+		d = *o.dialer
+	} else {
+		d = websocket.Dialer{
+			HandshakeTimeout: timeout,
+			// the TV uses a self-signed certificate
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			NetDial:         (&net.Dialer{Timeout: timeout}).Dial,
+		}
+	}
+
+	if o.tls != nil {
+		d.TLSClientConfig = o.tls
+	}
+	return &d
+}
+
+// dial connects to the TV's socket.
+func (o *options) dial(dialer *websocket.Dialer, host string) (*websocket.Conn, error) {
+	protocol, port := "wss", securePort
+	if o.insecure {
+		protocol, port = "ws", insecurePort
+	}
+	if o.port != 0 {
+		port = o.port
+	}
+
+	addr := fmt.Sprintf("%s://%s", protocol, net.JoinHostPort(host, fmt.Sprint(port)))
+	ws, resp, err := dialer.Dial(addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial %s: %w", addr, err)
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		ws.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// TLSMode is how the connection to a TV is secured, e.g. by a DeviceConfig.
+type TLSMode string
+
+const (
+	// TLSInsecure connects using wss on port 3001 without verifying the TV's
+	// self-signed certificate, falling back to ws on port 3000 if the TV refuses the
+	// connection, unless the certificate is pinned. It's the default.
+	TLSInsecure TLSMode = "insecure"
+
+	// TLSVerify connects using wss on port 3001, verifying the TV's certificate
+	// against the system's root certificates.
+	TLSVerify TLSMode = "verify"
+
+	// TLSNone connects using ws on port 3000, as used by older TVs.
+	TLSNone TLSMode = "none"
+)
+
+// Validate returns an error if the TLSMode isn't known. The empty TLSMode is
+// TLSInsecure.
+func (m TLSMode) Validate() error {
+	switch m {
+	case "", TLSInsecure, TLSVerify, TLSNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown TLS mode: %s", m)
+	}
+}
+
+// options returns the Options connecting using the TLSMode.
+func (m TLSMode) options() []Option {
+	switch m {
+	case TLSVerify:
+		// the port is set so it doesn't fall back to ws
+		return []Option{WithTLSConfig(&tls.Config{}), WithPort(securePort)}
+	case TLSNone:
+		return []Option{WithInsecureWS()}
+	default:
+		return nil
+	}
+}
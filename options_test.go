@@ -0,0 +1,27 @@
+package webos
+
+import "testing"
+
+func TestFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"default", nil, true},
+		{"without fallback", []Option{WithoutFallback()}, false},
+		{"pinned", []Option{WithCertificatePin("0f")}, false},
+		{"port", []Option{WithPort(3001)}, false},
+		{"ws", []Option{WithInsecureWS()}, false},
+		{"device without fingerprint", DeviceConfig{Address: "tv"}.DialOptions(), false},
+		{"device using ws", DeviceConfig{Address: "tv", TLS: TLSNone}.DialOptions(), false},
+	} {
+		var o options
+		for _, opt := range tc.opts {
+			opt(&o)
+		}
+		if got := o.fallback(); got != tc.want {
+			t.Errorf("%s: fallback is %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
@@ -1,11 +1,112 @@
 package webos
 
+import "github.com/pkg/errors"
+
+// ManifestPresets are the named sets of permissions requested when pairing, in addition
+// to the permissions of the signed manifest which are always requested.
+var ManifestPresets = map[string][]string{
+	// full requests every permission used by the TV methods.
+	"full": {
+		"TEST_SECURE",
+		"CONTROL_INPUT_TEXT",
+		"CONTROL_MOUSE_AND_KEYBOARD",
+		"READ_INSTALLED_APPS",
+		"READ_LGE_SDX",
+		"READ_NOTIFICATIONS",
+		"SEARCH",
+		"WRITE_SETTINGS",
+		"WRITE_NOTIFICATION_ALERT",
+		"CONTROL_POWER",
+		"READ_CURRENT_CHANNEL",
+		"READ_RUNNING_APPS",
+		"READ_UPDATE_INFO",
+		"UPDATE_FROM_REMOTE_APP",
+		"READ_LGE_TV_INPUT_EVENTS",
+		"READ_TV_CURRENT_TIME",
+	},
+
+	// basic doesn't request control of the mouse, keyboard or settings.
+	"basic": {
+		"READ_INSTALLED_APPS",
+		"READ_NOTIFICATIONS",
+		"CONTROL_POWER",
+		"READ_CURRENT_CHANNEL",
+		"READ_RUNNING_APPS",
+		"READ_LGE_TV_INPUT_EVENTS",
+		"READ_TV_CURRENT_TIME",
+	},
+}
+
+// signedPermissions are the permissions of the signed manifest, which are requested
+// regardless of the ManifestPreset.
+var signedPermissions = []string{
+	"LAUNCH",
+	"LAUNCH_WEBAPP",
+	"APP_TO_APP",
+	"CLOSE",
+	"TEST_OPEN",
+	"TEST_PROTECTED",
+	"CONTROL_AUDIO",
+	"CONTROL_DISPLAY",
+	"CONTROL_INPUT_JOYSTICK",
+	"CONTROL_INPUT_MEDIA_RECORDING",
+	"CONTROL_INPUT_MEDIA_PLAYBACK",
+	"CONTROL_INPUT_TV",
+	"CONTROL_POWER",
+	"READ_APP_STATUS",
+	"READ_CURRENT_CHANNEL",
+	"READ_INPUT_DEVICE_LIST",
+	"READ_NETWORK_STATE",
+	"READ_RUNNING_APPS",
+	"READ_TV_CHANNEL_LIST",
+	"WRITE_NOTIFICATION_TOAST",
+	"READ_POWER_STATE",
+	"READ_COUNTRY_INFO",
+}
+
+// DefaultManifestPreset is the ManifestPreset used unless another is set.
+const DefaultManifestPreset = "full"
+
+// SetManifestPreset sets the ManifestPreset requested when pairing with the TV. When
+// registering with a client key, the ManifestPreset the key was paired with should be
+// set if it isn't the DefaultManifestPreset, so Commands requiring permissions which
+// weren't granted fail without making a request, see Permissions.
+func (tv *TV) SetManifestPreset(name string) error {
+	permissions, ok := ManifestPresets[name]
+	if !ok {
+		return errors.Errorf("unknown manifest preset: %s", name)
+	}
+
+	tv.permissions = permissions
+	return nil
+}
+
 // pairPrompt returns a Payload necessary to pair with the TV using
 // the PROMPT method.
-func pairPrompt() Payload {
+func (tv *TV) pairPrompt() Payload {
+	return pairingPayload("PROMPT", tv.manifestPermissions())
+}
+
+// pairPIN returns a Payload necessary to pair with the TV using
+// the PIN method.
+func (tv *TV) pairPIN() Payload {
+	return pairingPayload("PIN", tv.manifestPermissions())
+}
+
+// manifestPermissions returns the permissions of the ManifestPreset.
+func (tv *TV) manifestPermissions() []string {
+	if tv.permissions == nil {
+		return ManifestPresets[DefaultManifestPreset]
+	}
+	return tv.permissions
+}
+
+// pairingPayload returns a Payload necessary to pair with the TV using
+// the given pairing type, requesting the permissions.
+func pairingPayload(pairingType string, permissions []string) Payload {
 	return Payload{
 		"forcePairing": false,
-		"pairingType":  "PROMPT",
+		"pairingType":  pairingType,
 		"manifest": map[string]interface{}{
 			"manifestVersion": 1,
 			"appVersion":      "1.1",
@@ -19,50 +120,10 @@ func pairPrompt() Payload {
 				"localizedVendorNames": map[string]string{
 					"": "LG Electronics",
 				},
-				"permissions": []string{
-					"LAUNCH",
-					"LAUNCH_WEBAPP",
-					"APP_TO_APP",
-					"CLOSE",
-					"TEST_OPEN",
-					"TEST_PROTECTED",
-					"CONTROL_AUDIO",
-					"CONTROL_DISPLAY",
-					"CONTROL_INPUT_JOYSTICK",
-					"CONTROL_INPUT_MEDIA_RECORDING",
-					"CONTROL_INPUT_MEDIA_PLAYBACK",
-					"CONTROL_INPUT_TV",
-					"CONTROL_POWER",
-					"READ_APP_STATUS",
-					"READ_CURRENT_CHANNEL",
-					"READ_INPUT_DEVICE_LIST",
-					"READ_NETWORK_STATE",
-					"READ_RUNNING_APPS",
-					"READ_TV_CHANNEL_LIST",
-					"WRITE_NOTIFICATION_TOAST",
-					"READ_POWER_STATE",
-					"READ_COUNTRY_INFO",
-				},
-				"serial": "2f930e2d2cfe083771f68e4fe7bb07",
-			},
-			"permissions": []string{
-				"TEST_SECURE",
-				"CONTROL_INPUT_TEXT",
-				"CONTROL_MOUSE_AND_KEYBOARD",
-				"READ_INSTALLED_APPS",
-				"READ_LGE_SDX",
-				"READ_NOTIFICATIONS",
-				"SEARCH",
-				"WRITE_SETTINGS",
-				"WRITE_NOTIFICATION_ALERT",
-				"CONTROL_POWER",
-				"READ_CURRENT_CHANNEL",
-				"READ_RUNNING_APPS",
-				"READ_UPDATE_INFO",
-				"UPDATE_FROM_REMOTE_APP",
-				"READ_LGE_TV_INPUT_EVENTS",
-				"READ_TV_CURRENT_TIME",
+				"permissions": signedPermissions,
+				"serial":      "2f930e2d2cfe083771f68e4fe7bb07",
 			},
+			"permissions": permissions,
 			"signatures": []map[string]interface{}{
 				{
 					"signatureVersion": 1,
@@ -72,10 +133,3 @@ func pairPrompt() Payload {
 		},
 	}
 }
-
-// pairPIN returns a Payload necessary to pair with the TV using
-// the PIN method.
-//  @todo implement PIN pairing.
-func pairPIN() Payload {
-	panic("not implemented")
-}
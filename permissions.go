@@ -0,0 +1,101 @@
+package webos
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PermissionError describes a Command which the client isn't permitted to execute, as
+// the permission it requires wasn't granted when registering. It is returned without
+// making a request, or when the TV rejects a Command which requires a permission.
+// PermissionErrors match ErrPermissionDenied using errors.Is.
+type PermissionError struct {
+	URI Command
+
+	// Required is the permission required by the Command, e.g. "CONTROL_POWER".
+	Required string
+
+	// Err is the error returned by the TV, or nil if the request wasn't made.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PermissionError) Error() string {
+	msg := fmt.Sprintf("%s: %s: requires %s", ErrPermissionDenied, e.URI, e.Required)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is ErrPermissionDenied.
+func (e *PermissionError) Is(target error) bool {
+	return target == ErrPermissionDenied
+}
+
+// Unwrap returns the error returned by the TV, if any.
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// Permissions returns the permissions granted when registering, sorted, or nil before
+// registering.
+func (tv *TV) Permissions() []string {
+	if tv.granted == nil {
+		return nil
+	}
+
+	permissions := make([]string, 0, len(tv.granted))
+	for p := range tv.granted {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// Permitted returns true if the permission required by the Command was granted when
+// registering. All Commands are considered permitted before registering.
+func (tv *TV) Permitted(uri Command) bool {
+	return tv.checkPermission(uri) == nil
+}
+
+// checkPermission returns a *PermissionError if the permission required by the Command
+// wasn't granted.
+func (tv *TV) checkPermission(uri Command) error {
+	required := uri.Permission()
+	if tv.granted == nil || required == "" || tv.granted[required] {
+		return nil
+	}
+	return &PermissionError{URI: uri, Required: required}
+}
+
+// grantPermissions records the permissions granted when registering: those requested
+// by the manifest when pairing. Registering with a client key grants the permissions
+// the key was paired with, which are assumed to be those of the ManifestPreset, the
+// DefaultManifestPreset unless another is set.
+func (tv *TV) grantPermissions() {
+	granted := make(map[string]bool)
+	for _, p := range signedPermissions {
+		granted[p] = true
+	}
+	for _, p := range tv.manifestPermissions() {
+		granted[p] = true
+	}
+	tv.granted = granted
+}
+
+// permissionError returns a *PermissionError wrapping the TV's error if it rejected a
+// Command which requires a permission, e.g. as the client key was paired with a
+// reduced ManifestPreset, so the error names the missing permission.
+func permissionError(uri Command, err error) error {
+	apiErr, ok := err.(*APIError)
+	if !ok || !apiErr.Is(ErrPermissionDenied) {
+		return err
+	}
+
+	required := uri.Permission()
+	if required == "" {
+		return err
+	}
+	return &PermissionError{URI: uri, Required: required, Err: apiErr}
+}
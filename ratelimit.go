@@ -0,0 +1,182 @@
+package webos
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CoalescedCommands are the Commands where only the latest value matters, suitable
+// for coalescing by RateLimitMiddleware.
+var CoalescedCommands = []Command{AudioSetVolumeCommand}
+
+// RateLimitMiddleware sends requests at most once per interval, in the order they
+// are made, e.g. to stop a slider flooding the TV. Requests wait for their turn, or
+// until their context is done, when they leave the queue and the requests behind them
+// move up.
+//
+// Requests for the coalesce Commands, e.g. CoalescedCommands, are "last value wins":
+// a queued request is dropped, returning ErrSuperseded, when a newer request for the
+// same Command is made, and the newer request takes its place in the queue.
+func RateLimitMiddleware(interval time.Duration, coalesce ...Command) Middleware {
+	l := &limiter{
+		interval: interval,
+		coalesce: make(map[Command]bool, len(coalesce)),
+		queued:   make(map[Command]*slot),
+	}
+	for _, uri := range coalesce {
+		l.coalesce[uri] = true
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (Message, error) {
+			if err := l.wait(ctx, msg.URI); err != nil {
+				return Message{}, err
+			}
+			return next(ctx, msg)
+		}
+	}
+}
+
+// limiter schedules requests once per interval, in the order they are queued.
+type limiter struct {
+	interval time.Duration
+	coalesce map[Command]bool
+
+	mu     sync.Mutex
+	last   time.Time
+	queue  []*slot
+	queued map[Command]*slot
+}
+
+// slot is the place of a request in the queue.
+type slot struct {
+	uri Command
+
+	// turn is signalled when the slot reaches the front of the queue
+	turn       chan struct{}
+	superseded chan struct{}
+	dropped    bool
+}
+
+// wait waits for the request's turn. A request whose context is done leaves the
+// queue, so the requests behind it move up.
+func (l *limiter) wait(ctx context.Context, uri Command) error {
+	s := l.reserve(uri)
+
+	for {
+		delay, err := l.take(s)
+		if delay <= 0 || err != nil {
+			return err
+		}
+
+		var t *time.Timer
+		var expired <-chan time.Time
+		if delay != waiting {
+			t = time.NewTimer(delay)
+			expired = t.C
+		}
+
+		select {
+		case <-expired:
+		case <-s.turn:
+		case <-s.superseded:
+		case <-ctx.Done():
+			if t != nil {
+				t.Stop()
+			}
+			return l.leave(s, ctx.Err())
+		}
+
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// waiting is the delay returned by take for a slot which isn't at the front of the queue.
+const waiting = time.Duration(1<<63 - 1)
+
+// take removes the slot from the queue if it's the request's turn, returning zero, or
+// returns how long to wait until its turn, which is waiting if it isn't at the front.
+func (l *limiter) take(s *slot) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if s.dropped {
+		return 0, ErrSuperseded
+	}
+	if l.queue[0] != s {
+		return waiting, nil
+	}
+
+	if delay := time.Until(l.last.Add(l.interval)); delay > 0 {
+		return delay, nil
+	}
+
+	l.last = time.Now()
+	l.remove(0)
+	return 0, nil
+}
+
+// leave removes the slot of a request whose context is done from the queue, returning
+// err, or ErrSuperseded if a newer request took its place.
+func (l *limiter) leave(s *slot, err error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if s.dropped {
+		return ErrSuperseded
+	}
+	for i, queued := range l.queue {
+		if queued == s {
+			l.remove(i)
+			break
+		}
+	}
+	return err
+}
+
+// remove removes the slot at index i of the queue, telling the slot now at the front
+// that it's its turn. mu must be held.
+func (l *limiter) remove(i int) {
+	s := l.queue[i]
+	l.queue = append(l.queue[:i], l.queue[i+1:]...)
+	if l.queued[s.uri] == s {
+		delete(l.queued, s.uri)
+	}
+
+	if i == 0 && len(l.queue) > 0 {
+		select {
+		case l.queue[0].turn <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// reserve queues a slot for the request, or takes the place of the queued request
+// for the Command if it is coalesced.
+func (l *limiter) reserve(uri Command) *slot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := &slot{uri: uri, turn: make(chan struct{}, 1), superseded: make(chan struct{})}
+
+	if old, ok := l.queued[uri]; ok {
+		old.dropped = true
+		close(old.superseded)
+		for i, queued := range l.queue {
+			if queued == old {
+				l.queue[i] = s
+				break
+			}
+		}
+	} else {
+		l.queue = append(l.queue, s)
+	}
+
+	if l.coalesce[uri] {
+		l.queued[uri] = s
+	}
+	return s
+}
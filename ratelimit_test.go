@@ -0,0 +1,93 @@
+package webos_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// sendTimes returns a Handler recording when each request is sent, by URI.
+func sendTimes() (webos.Handler, func(webos.Command) time.Time) {
+	var mu sync.Mutex
+	sent := make(map[webos.Command]time.Time)
+
+	h := func(ctx context.Context, msg *webos.Message) (webos.Message, error) {
+		mu.Lock()
+		sent[msg.URI] = time.Now()
+		mu.Unlock()
+		return webos.Message{Type: webos.ResponseMessageType, ID: msg.ID}, nil
+	}
+	return h, func(uri webos.Command) time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return sent[uri]
+	}
+}
+
+func TestRateLimitCancelled(t *testing.T) {
+	next, sent := sendTimes()
+	h := webos.RateLimitMiddleware(200 * time.Millisecond)(next)
+
+	start := time.Now()
+	if _, err := h(context.Background(), &webos.Message{URI: webos.AudioGetVolumeCommand}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second request is cancelled while queued, so the third takes its turn
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := h(ctx, &webos.Message{URI: webos.AudioVolumeUpCommand})
+		cancelled <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := h(context.Background(), &webos.Message{URI: webos.AudioVolumeDownCommand})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled request returned %v, want context.Canceled", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if !sent(webos.AudioVolumeUpCommand).IsZero() {
+		t.Error("the cancelled request was sent")
+	}
+	if d := sent(webos.AudioVolumeDownCommand).Sub(start); d < 200*time.Millisecond || d > 300*time.Millisecond {
+		t.Errorf("the request after the cancelled request was sent after %s, want 200ms", d)
+	}
+}
+
+func TestRateLimitCoalesced(t *testing.T) {
+	next, _ := sendTimes()
+	h := webos.RateLimitMiddleware(100*time.Millisecond, webos.CoalescedCommands...)(next)
+
+	if _, err := h(context.Background(), &webos.Message{URI: webos.AudioGetVolumeCommand}); err != nil {
+		t.Fatal(err)
+	}
+
+	superseded := make(chan error, 1)
+	go func() {
+		_, err := h(context.Background(), &webos.Message{URI: webos.AudioSetVolumeCommand, Payload: webos.Payload{"volume": 1}})
+		superseded <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := h(context.Background(), &webos.Message{URI: webos.AudioSetVolumeCommand, Payload: webos.Payload{"volume": 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-superseded; !errors.Is(err, webos.ErrSuperseded) {
+		t.Errorf("queued request returned %v, want ErrSuperseded", err)
+	}
+}
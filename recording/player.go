@@ -0,0 +1,112 @@
+package recording
+
+import (
+	"io"
+	"sync"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// Player is a fake TV which replays a recording. Requests are answered with the
+// responses recorded for the same URI, in the order they were recorded. Once the
+// recorded responses for a URI are exhausted the last response is repeated.
+type Player struct {
+	*webostest.Server
+
+	mu        sync.Mutex
+	responses map[webos.Command][]webos.Message
+	events    map[webos.Command][]webos.Payload
+}
+
+// NewPlayer loads a recording from r and starts a Player. The Player should be
+// closed when finished.
+func NewPlayer(r io.Reader) (*Player, error) {
+	entries, err := Load(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Player{
+		Server:    webostest.NewServer(),
+		responses: make(map[webos.Command][]webos.Message),
+		events:    make(map[webos.Command][]webos.Payload),
+	}
+
+	// client keys are redacted from recordings, so any key is accepted
+	p.AcceptAnyClientKey = true
+
+	requests := make(map[string]webos.Message)
+	subscribed := make(map[string]bool)
+	for _, e := range entries {
+		msg := e.Message
+
+		switch e.Direction {
+		case Outbound:
+			if msg.Type == webos.RequestMessageType || msg.Type == webos.SubscribeMessageType {
+				requests[msg.ID] = msg
+			}
+		case Inbound:
+			req, ok := requests[msg.ID]
+			if !ok {
+				continue
+			}
+
+			// the first response to a subscription is its initial state, subsequent
+			// responses are events
+			if subscribed[msg.ID] {
+				p.events[req.URI] = append(p.events[req.URI], msg.Payload)
+				continue
+			}
+
+			p.responses[req.URI] = append(p.responses[req.URI], msg)
+			if req.Type == webos.SubscribeMessageType {
+				subscribed[msg.ID] = true
+			} else {
+				delete(requests, msg.ID)
+			}
+		}
+	}
+
+	for uri := range p.responses {
+		uri := uri
+		p.Handle(uri, func(webos.Message) webos.Message {
+			return p.next(uri)
+		})
+	}
+
+	return p, nil
+}
+
+// PlayEvents publishes the subscription events recorded for uri, in the order they
+// were recorded, to every subscription to uri.
+func (p *Player) PlayEvents(uri webos.Command) {
+	p.mu.Lock()
+	events := p.events[uri]
+	p.mu.Unlock()
+
+	for _, e := range events {
+		p.Publish(uri, e)
+	}
+}
+
+// next returns the next recorded response for uri.
+func (p *Player) next(uri webos.Command) webos.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	responses := p.responses[uri]
+	res := responses[0]
+	if len(responses) > 1 {
+		p.responses[uri] = responses[1:]
+	}
+
+	// copy the Payload as the Server modifies subscription responses
+	payload := make(webos.Payload, len(res.Payload))
+	for k, v := range res.Payload {
+		payload[k] = v
+	}
+	res.Payload = payload
+
+	return res
+}
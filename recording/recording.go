@@ -0,0 +1,129 @@
+// Package recording records SSAP sessions with a TV and replays them using a fake TV,
+// so issues reported against real hardware can be reproduced deterministically.
+//
+// Sessions are recorded by wrapping the connection used by the TV:
+//
+//	ws, _, err := dialer.Dial("wss://<tv-ipv4-address>:3001", nil)
+//	...
+//	f, err := os.Create("session.jsonl")
+//	...
+//	tv := webos.NewTVConn(recording.NewConn(ws, f))
+//
+// and replayed with a Player:
+//
+//	p, err := recording.NewPlayer(f)
+//	...
+//	tv, err := webos.Dial(p.Host(), webos.WithDialer(p.Dialer()))
+package recording
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// Redacted replaces client keys and PINs in recorded Messages.
+const Redacted = webos.Redacted
+
+// Direction is the direction of a recorded Message.
+type Direction string
+
+const (
+	// Outbound Messages are sent to the TV.
+	Outbound Direction = "out"
+
+	// Inbound Messages are received from the TV.
+	Inbound Direction = "in"
+)
+
+// Entry is a single recorded Message. Recordings are stored as one JSON encoded
+// Entry per line.
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Direction Direction     `json:"direction"`
+	Message   webos.Message `json:"message"`
+}
+
+// Conn wraps a webos.Conn and records every Message sent and received.
+type Conn struct {
+	conn webos.Conn
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewConn returns a Conn which records Messages to w.
+func NewConn(conn webos.Conn, w io.Writer) *Conn {
+	return &Conn{conn: conn, enc: json.NewEncoder(w)}
+}
+
+// ReadMessage reads a message from the wrapped connection and records it.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	mt, p, err := c.conn.ReadMessage()
+	if err == nil && mt == websocket.TextMessage {
+		c.record(Inbound, p)
+	}
+	return mt, p, err
+}
+
+// WriteMessage records a message and writes it to the wrapped connection.
+func (c *Conn) WriteMessage(mt int, data []byte) error {
+	if mt == websocket.TextMessage {
+		c.record(Outbound, data)
+	}
+	return c.conn.WriteMessage(mt, data)
+}
+
+// Close closes the wrapped connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Err returns the first error encountered while recording, if any. Recording errors
+// don't interrupt the session.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// record writes an Entry for the message p, with its secrets redacted using
+// webos.Redact. Messages which can't be decoded are skipped.
+func (c *Conn) record(d Direction, p []byte) {
+	msg := webos.Message{}
+	if err := json.Unmarshal(p, &msg); err != nil {
+		return
+	}
+	msg.Payload = webos.Redact(msg.Payload)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.enc.Encode(Entry{Time: time.Now(), Direction: d, Message: msg}); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+// Load reads a recording. Entries aren't limited in size, e.g. a long app list.
+func Load(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	dec := json.NewDecoder(r)
+	for {
+		e := Entry{}
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
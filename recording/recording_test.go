@@ -0,0 +1,123 @@
+package recording_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/recording"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// record pairs with the Server using its PIN and gets the volume and apps, recording
+// the session.
+func record(t *testing.T, srv *webostest.Server) []byte {
+	t.Helper()
+
+	ws, _, err := srv.Dialer().Dial("wss://"+srv.Host()+":3001", nil)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+
+	var buf bytes.Buffer
+	conn := recording.NewConn(ws, &buf)
+	tv := webos.NewTVConn(conn)
+	go tv.MessageHandler()
+
+	if _, err := tv.AuthorisePIN(func() (string, error) { return webostest.DefaultPIN, nil }); err != nil {
+		t.Fatalf("AuthorisePIN: %v", err)
+	}
+	if _, err := tv.GetVolume(); err != nil {
+		t.Fatalf("GetVolume: %v", err)
+	}
+	if _, err := tv.ListApps(); err != nil {
+		t.Fatalf("ListApps: %v", err)
+	}
+	tv.Close()
+
+	if err := conn.Err(); err != nil {
+		t.Fatalf("could not record: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// apps returns an app list whose response is larger than a megabyte.
+func apps(n int) []interface{} {
+	apps := make([]interface{}, n)
+	for i := range apps {
+		apps[i] = map[string]interface{}{"id": fmt.Sprintf("com.example.app%d", i), "title": "An app with a long title", "version": "1.0", "visible": true}
+	}
+	return apps
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"volumeStatus": map[string]interface{}{"volume": 12, "muteStatus": true}})
+	srv.Respond(webos.ApplicationManagerListAppsCommand, webos.Payload{"apps": apps(15000)})
+
+	rec := record(t, srv)
+	if len(rec) < 1024*1024 {
+		t.Fatalf("the recording is %d bytes, want more than a megabyte", len(rec))
+	}
+
+	entries, err := recording.Load(bytes.NewReader(rec))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("nothing was recorded")
+	}
+
+	p, err := recording.NewPlayer(bytes.NewReader(rec))
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	defer p.Close()
+
+	tv, err := webos.Dial(p.Host(), webos.WithDialer(p.Dialer()))
+	if err != nil {
+		t.Fatalf("could not dial the Player: %v", err)
+	}
+	defer tv.Close()
+	go tv.MessageHandler()
+
+	// the recorded client key was redacted, so any key is accepted
+	if err := tv.AuthoriseClientKey("another-key"); err != nil {
+		t.Fatalf("AuthoriseClientKey: %v", err)
+	}
+
+	v, err := tv.GetVolume()
+	if err != nil {
+		t.Fatalf("GetVolume: %v", err)
+	}
+	if v.Volume != 12 || !v.Muted {
+		t.Errorf("replayed volume is %+v, want 12 and muted", v)
+	}
+
+	installed, err := tv.ListApps()
+	if err != nil {
+		t.Fatalf("ListApps: %v", err)
+	}
+	if len(installed) != 15000 {
+		t.Errorf("replayed %d apps, want 15000", len(installed))
+	}
+}
+
+func TestRecordRedacts(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	rec := string(record(t, srv))
+	for _, secret := range []string{webostest.DefaultClientKey, webostest.DefaultPIN} {
+		if strings.Contains(rec, secret) {
+			t.Errorf("%q was recorded", secret)
+		}
+	}
+	if !strings.Contains(rec, recording.Redacted) {
+		t.Error("no secrets were redacted")
+	}
+}
@@ -0,0 +1,133 @@
+package webos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Subscription receives the responses the TV sends whenever the state returned by a
+// subscribed Command changes.
+type Subscription struct {
+	// URI is the subscribed Command.
+	URI Command
+
+	// ID is the Message ID used by the TV for the subscription responses.
+	ID string
+
+	// C receives the current state followed by every change. It is closed when the
+	// Subscription or the connection is closed. Responses are dropped if C isn't read.
+	C <-chan Message
+
+	tv *TV
+}
+
+// Subscribe subscribes to changes of the state returned by the Command. The TV's
+// first response is validated before the Subscription is returned.
+func (tv *TV) Subscribe(uri Command, req Payload) (*Subscription, error) {
+	if err := tv.Capabilities().check(uri); err != nil {
+		return nil, err
+	}
+	if err := tv.checkPermission(uri); err != nil {
+		return nil, err
+	}
+
+	msg := Message{
+		Type:    SubscribeMessageType,
+		ID:      tv.ids.next(),
+		URI:     uri,
+		Payload: req,
+	}
+
+	res, err := tv.requestContext(context.Background(), &msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// the responses are received using the ID of the request written to the TV, which
+	// Middleware may have changed
+	id := res.ID
+	if id == "" {
+		id = msg.ID
+	}
+
+	ch := tv.responseChannel(id)
+	if ch == nil {
+		// the Middleware responded without writing the request to the TV, e.g. in a
+		// dry run, so there are no more responses until the Subscription is closed
+		if ch, err = tv.setupResponseChannel(id, false); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(chan Message, 16)
+	out <- res
+
+	go func() {
+		defer close(out)
+		for res := range ch {
+			select {
+			case out <- res:
+			default:
+			}
+		}
+	}()
+
+	return &Subscription{URI: uri, ID: id, C: out, tv: tv}, nil
+}
+
+// subscribe writes the subscription request and waits for the first response. The
+// response channel is kept until the Subscription is closed. It is called by
+// roundTrip, wrapped by the Middleware.
+func (tv *TV) subscribe(ctx context.Context, msg *Message) (Message, error) {
+	ch, err := tv.setupResponseChannel(msg.ID, false)
+	if err != nil {
+		return Message{}, err
+	}
+
+	if err := tv.write(msg); err != nil {
+		tv.teardownResponseChannel(msg.ID)
+		return Message{}, err
+	}
+
+	res, err := tv.receive(ctx, msg, ch)
+	if err == nil {
+		err = tv.validate(msg, res)
+	}
+	if err != nil {
+		tv.teardownResponseChannel(msg.ID)
+		return Message{}, err
+	}
+	return res, nil
+}
+
+// Close cancels the Subscription and closes C.
+func (s *Subscription) Close() error {
+	defer s.tv.teardownResponseChannel(s.ID)
+
+	_, err := s.tv.requestContext(context.Background(), &Message{
+		Type: UnsubscribeMessageType,
+		ID:   s.ID,
+		URI:  s.URI,
+	})
+	if err != nil {
+		return fmt.Errorf("could not unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// Decode decodes a response received on C into v, e.g. a *Volume for a Subscription
+// to AudioGetVolumeCommand. Responses for Commands with known schemas are decoded
+// tolerating the differences between webOS versions.
+func (s *Subscription) Decode(msg Message, v interface{}) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	if d, ok := responseDecoders[s.URI]; ok {
+		return s.tv.decode(d.typ, msg.Payload, v, d.schemas)
+	}
+
+	return mapstructure.WeakDecode(msg.Payload, v)
+}
@@ -1,10 +1,14 @@
 package webos
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"math/rand"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,25 +16,63 @@ import (
 )
 
 var (
-	// Protocol is the protocol used to connect to the TV.
+	// Protocol is the protocol used to connect to the TV by NewTV.
+	//
+	// Deprecated: Protocol is shared by every connection, use Dial with
+	// WithInsecureWS instead.
 	Protocol = "wss"
 
-	// Port is the port used to connect to the TV.
+	// Port is the port used to connect to the TV by NewTV.
+	//
+	// Deprecated: Port is shared by every connection, use Dial with WithPort
+	// instead.
 	Port = 3001
 )
 
+// DefaultTimeout is how long to wait for the TV to respond to a request, unless set by
+// SetTimeout.
+const DefaultTimeout = 15 * time.Second
+
+// Conn is the connection used to send and receive Messages. It is satisfied by
+// *websocket.Conn and can be wrapped, e.g. to record sessions.
+type Conn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
 // TV represents the TV. It contains the websocket connection, necessary channels
 // used for communication and methods used for interaction with the TV.
 type TV struct {
-	ws      *websocket.Conn
+	ws      Conn
 	wsMutex sync.Mutex
 
-	res      map[string]chan<- Message
+	res      map[string]*pendingResponse
 	resMutex sync.Mutex
-	input    *Input
+	closed   bool // the MessageHandler has returned, so no more responses are received
+	ids      idGenerator
+
+	input      *Input
+	inputMutex sync.Mutex
+
+	strict      atomic.Bool
+	caps        atomic.Pointer[Capabilities]
+	metrics     Metrics
+	middleware  []Middleware
+	handlers    atomic.Pointer[Handler]
+	logger      *slog.Logger
+	permissions []string
+	granted     map[string]bool
+	timeout     atomic.Int64
+
+	// fingerprint is the fingerprint of the TV's certificate, and inputTLS verifies
+	// the pointer input socket uses the same certificate.
+	fingerprint string
+	inputTLS    *tls.Config
 }
 
-// NewTV dials the socket and returns a pointer to a new TV.
+// NewTV dials the socket using Protocol and Port and returns a pointer to a new TV.
+// Dial configures each connection separately, and falls back to ws for older TVs.
 func NewTV(dialer *websocket.Dialer, ip string) (*TV, error) {
 	addr := fmt.Sprintf("%s://%s:%d", Protocol, ip, Port)
 	ws, resp, err := dialer.Dial(addr, nil)
@@ -45,11 +87,32 @@ func NewTV(dialer *websocket.Dialer, ip string) (*TV, error) {
 	return tv, nil
 }
 
+// NewTVConn returns a pointer to a new TV using an established connection.
+func NewTVConn(conn Conn) *TV {
+	return &TV{ws: conn}
+}
+
 // Command executes a Command on the TV.
+// Commands which the TV's Capabilities, once detected, show to be unsupported return an
+// *UnsupportedError, and Commands requiring a permission which wasn't granted return a
+// *PermissionError, without making a request.
 func (tv *TV) Command(uri Command, req Payload) (Message, error) {
-	return tv.request(&Message{
+	return tv.CommandContext(context.Background(), uri, req)
+}
+
+// CommandContext executes a Command on the TV, returning the context's error if it
+// is done before the TV responds. The context is passed to the Middleware.
+func (tv *TV) CommandContext(ctx context.Context, uri Command, req Payload) (Message, error) {
+	if err := tv.Capabilities().check(uri); err != nil {
+		return Message{}, err
+	}
+	if err := tv.checkPermission(uri); err != nil {
+		return Message{}, err
+	}
+
+	return tv.requestContext(ctx, &Message{
 		Type:    RequestMessageType,
-		ID:      requestID(),
+		ID:      tv.ids.next(),
 		URI:     uri,
 		Payload: req,
 	})
@@ -59,12 +122,22 @@ func (tv *TV) Command(uri Command, req Payload) (Message, error) {
 // Responses are read into a Message type and added to appropriate channel
 // based on the Message.ID.
 func (tv *TV) MessageHandler() (err error) {
+	if tv.metrics != nil {
+		tv.metrics.Connected()
+	}
+
 	defer func() {
+		tv.log(slog.LevelInfo, "connection closed", slog.Any("error", err))
+		if tv.metrics != nil {
+			tv.metrics.Disconnected(err)
+		}
+
 		tv.resMutex.Lock()
-		for _, ch := range tv.res {
-			close(ch)
+		for _, p := range tv.res {
+			close(p.ch)
 		}
 		tv.res = nil
+		tv.closed = true
 		tv.resMutex.Unlock()
 	}()
 
@@ -75,6 +148,7 @@ func (tv *TV) MessageHandler() (err error) {
 		}
 
 		if mt != websocket.TextMessage {
+			tv.log(slog.LevelDebug, "ignoring non-text message", slog.Int("type", mt))
 			continue
 		}
 
@@ -82,54 +156,170 @@ func (tv *TV) MessageHandler() (err error) {
 
 		err = json.Unmarshal(p, &msg)
 		if err != nil {
+			tv.log(slog.LevelWarn, "could not decode message", slog.Any("error", err), slog.Int("size", len(p)))
 			continue
 		}
 
+		// messages which aren't awaited, or arrive after the request has been torn
+		// down, are dropped
+		var sent time.Time
 		tv.resMutex.Lock()
-		ch := tv.res[msg.ID]
+		pending, ok := tv.res[msg.ID]
+		delivered := false
+		if ok {
+			sent = pending.sent
+			select {
+			case pending.ch <- msg:
+				delivered = true
+			default:
+			}
+		}
 		tv.resMutex.Unlock()
 
-		ch <- msg
+		// logging is done once unlocked, so slow handlers don't hold up requests
+		tv.logMessage("receive", msg, sent)
+		switch {
+		case !ok:
+			tv.log(slog.LevelDebug, "dropped unmatched message", slog.String("id", msg.ID))
+		case !delivered:
+			tv.log(slog.LevelWarn, "dropped message, the receiver isn't reading", slog.String("id", msg.ID))
+		}
 	}
 }
 
 // AuthoriseClientKey autorises with the TV using an existing client key.
 func (tv *TV) AuthoriseClientKey(key string) error {
+	return tv.AuthoriseClientKeyContext(context.Background(), key)
+}
+
+// AuthoriseClientKeyContext is AuthoriseClientKey with a context, which is passed to
+// the Middleware.
+func (tv *TV) AuthoriseClientKeyContext(ctx context.Context, key string) error {
 	msg := Message{
 		Type:    RegisterMessageType,
-		ID:      requestID(),
+		ID:      tv.ids.next(),
 		Payload: Payload{"client-key": key},
 	}
 
-	res, err := tv.request(&msg)
+	res, err := tv.requestContext(ctx, &msg)
 	if err != nil {
-		return fmt.Errorf("could not make request: %v", err)
+		return fmt.Errorf("could not make request: %w", err)
 	}
 
 	if rt := res.Type; rt != RegisteredMessageType {
 		return fmt.Errorf("unexpected response type: %s", rt)
 	}
 
+	tv.grantPermissions()
+
 	return nil
 }
 
 // AuthorisePrompt autorises with the TV using the PROMPT method.
 func (tv *TV) AuthorisePrompt() (string, error) {
+	return tv.AuthorisePromptContext(context.Background())
+}
+
+// AuthorisePromptContext is AuthorisePrompt with a context, which is passed to the
+// Middleware. Waiting for the prompt to be accepted stops when the context is done.
+func (tv *TV) AuthorisePromptContext(ctx context.Context) (string, error) {
 	msg := Message{
 		Type:    RegisterMessageType,
-		ID:      requestID(),
-		Payload: pairPrompt(),
+		ID:      tv.ids.next(),
+		Payload: tv.pairPrompt(),
 	}
 
-	res, err := tv.request(&msg)
+	res, err := tv.requestContext(ctx, &msg)
+	if err != nil {
+		return "", fmt.Errorf("could not make request: %w", err)
+	}
+
+	if rt := res.Type; rt != RegisteredMessageType {
+		return "", fmt.Errorf("unexpected response type: %s", rt)
+	}
+
+	key, err := clientKey(res)
+	if err != nil {
+		return "", err
+	}
+
+	tv.grantPermissions()
+
+	return key, nil
+}
+
+// AuthorisePIN autorises with the TV using the PIN method. pin is called once the
+// TV displays the PIN and must return the PIN entered by the user.
+func (tv *TV) AuthorisePIN(pin func() (string, error)) (string, error) {
+	return tv.AuthorisePINContext(context.Background(), pin)
+}
+
+// AuthorisePINContext is AuthorisePIN with a context, which is passed to the
+// Middleware when setting the PIN. Waiting for the TV stops when the context is done.
+func (tv *TV) AuthorisePINContext(ctx context.Context, pin func() (string, error)) (string, error) {
+	msg := Message{
+		Type:    RegisterMessageType,
+		ID:      tv.ids.next(),
+		Payload: tv.pairPIN(),
+	}
+
+	ch, err := tv.setupResponseChannel(msg.ID, true)
+	if err != nil {
+		return "", err
+	}
+	defer tv.teardownResponseChannel(msg.ID)
+
+	if err := tv.write(&msg); err != nil {
+		return "", fmt.Errorf("could not make request: %w", err)
+	}
+
+	// the TV responds once the PIN is displayed, and again once registered
+	res, err := tv.receive(ctx, &msg, ch)
 	if err != nil {
-		return "", fmt.Errorf("could not make request: %v", err)
+		return "", fmt.Errorf("could not make request: %w", err)
+	}
+
+	if rt := res.Type; rt != ResponseMessageType {
+		if err := tv.validate(&msg, res); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("unexpected response type: %s", rt)
+	}
+
+	p, err := pin()
+	if err != nil {
+		return "", fmt.Errorf("could not read PIN: %w", err)
+	}
+
+	if _, err := tv.CommandContext(ctx, PairingSetPINCommand, Payload{"pin": p}); err != nil {
+		return "", fmt.Errorf("could not set PIN: %w", err)
+	}
+
+	res, err = tv.receive(ctx, &msg, ch)
+	if err != nil {
+		return "", fmt.Errorf("could not make request: %w", err)
+	}
+
+	if err := tv.validate(&msg, res); err != nil {
+		return "", err
 	}
 
 	if rt := res.Type; rt != RegisteredMessageType {
 		return "", fmt.Errorf("unexpected response type: %s", rt)
 	}
 
+	key, err := clientKey(res)
+	if err != nil {
+		return "", err
+	}
+
+	tv.grantPermissions()
+
+	return key, nil
+}
+
+// clientKey returns the client key from a registered response.
+func clientKey(res Message) (string, error) {
 	key := ""
 	if k, ok := res.Payload["client-key"]; ok {
 		k, ok := k.(string)
@@ -138,16 +328,20 @@ func (tv *TV) AuthorisePrompt() (string, error) {
 		}
 		key = k
 	}
-
 	return key, nil
 }
 
 // Close closes the websocket connection to the TV.
 func (tv *TV) Close() error {
+	tv.log(slog.LevelInfo, "closing connection")
+
+	tv.inputMutex.Lock()
 	if tv.input != nil {
 		tv.input.Close()
 		tv.input = nil
 	}
+	tv.inputMutex.Unlock()
+
 	return tv.ws.Close()
 }
 
@@ -155,94 +349,215 @@ func (tv *TV) Close() error {
 // using the given Message.ID and makes the request. Responses from the TV are added
 // to the channel in the MessageHandler method, and read in this method. Responses
 // are vaildates before they are returned.
+//
+// Errors can be tested using errors.Is with ErrTimeout, ErrConnectionClosed and the
+// sentinel errors matched by *APIError.
 func (tv *TV) request(msg *Message) (Message, error) {
-	ch := tv.setupResponseChannel(msg.ID)
-	defer tv.teardownResponseChannel(msg.ID)
+	return tv.requestContext(context.Background(), msg)
+}
+
+// requestContext makes a request to the TV through the Middleware.
+func (tv *TV) requestContext(ctx context.Context, msg *Message) (Message, error) {
+	return tv.handler()(ctx, msg)
+}
+
+// roundTrip writes the Message and waits for its response. It is the Handler
+// wrapped by the Middleware. Subscriptions keep receiving responses once the first is
+// returned, and unsubscribing returns without waiting, as the TV doesn't respond.
+func (tv *TV) roundTrip(ctx context.Context, msg *Message) (Message, error) {
+	switch msg.Type {
+	case SubscribeMessageType:
+		return tv.subscribe(ctx, msg)
+	case UnsubscribeMessageType:
+		return Message{Type: ResponseMessageType, ID: msg.ID}, tv.write(msg)
+	}
 
-	b, err := json.Marshal(msg)
+	ch, err := tv.setupResponseChannel(msg.ID, true)
 	if err != nil {
-		return Message{}, fmt.Errorf("could not marshall request: %v", err)
+		return Message{}, err
+	}
+	defer tv.teardownResponseChannel(msg.ID)
+
+	if err := tv.write(msg); err != nil {
+		return Message{}, err
+	}
+
+	for {
+		res, err := tv.receive(ctx, msg, ch)
+		if err != nil {
+			return Message{}, err
+		}
+
+		if res.Type == ResponseMessageType && msg.Type == RegisterMessageType {
+			continue
+		}
+
+		return res, tv.validate(msg, res)
+	}
+}
+
+// write encodes the Message and writes it to the websocket.
+func (tv *TV) write(msg *Message) error {
+	buf := encodeBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			encodeBuffers.Put(buf)
+		}
+	}()
+
+	if err := encodeMessage(buf, msg); err != nil {
+		return fmt.Errorf("could not marshall request: %v", err)
 	}
 
 	tv.wsMutex.Lock()
-	err = tv.ws.WriteMessage(websocket.TextMessage, b)
+	err := tv.ws.WriteMessage(websocket.TextMessage, buf.Bytes())
 	tv.wsMutex.Unlock()
 
 	if err != nil {
-		return Message{}, fmt.Errorf("could not write to socket: %v", err)
+		tv.log(slog.LevelWarn, "could not write message", slog.String("id", msg.ID), slog.Any("error", err))
+		return fmt.Errorf("could not write to socket: %v: %w", err, ErrConnectionClosed)
 	}
 
-	for {
-		select {
-		case res, ok := <-ch:
-			if !ok {
-				return Message{}, errors.New("no response")
-			}
+	tv.logMessage("send", *msg, time.Time{})
+	return nil
+}
 
-			if res.Type == ResponseMessageType && msg.Type == RegisterMessageType {
-				break
-			}
+// receive waits for the next response to msg on the channel.
+func (tv *TV) receive(ctx context.Context, msg *Message, ch <-chan Message) (Message, error) {
+	t := getTimer(tv.requestTimeout())
+	defer putTimer(t)
 
-			return res, res.Validate()
-		case <-time.After(time.Second * 15):
-			return Message{}, errors.New("timeout")
+	select {
+	case res, ok := <-ch:
+		if !ok {
+			return Message{}, fmt.Errorf("no response: %w", ErrConnectionClosed)
 		}
+		return res, nil
+	case <-t.C:
+		return Message{}, fmt.Errorf("%s: %w", msg.URI, ErrTimeout)
+	case <-ctx.Done():
+		return Message{}, fmt.Errorf("%s: %w", msg.URI, ctx.Err())
+	}
+}
+
+// SetTimeout sets how long to wait for the TV to respond to a request before returning
+// ErrTimeout. It defaults to DefaultTimeout.
+func (tv *TV) SetTimeout(d time.Duration) {
+	tv.timeout.Store(int64(d))
+}
+
+// requestTimeout returns how long to wait for a response.
+func (tv *TV) requestTimeout() time.Duration {
+	d := time.Duration(tv.timeout.Load())
+	if d <= 0 {
+		return DefaultTimeout
+	}
+	return d
+}
+
+// validate validates the response res to msg.
+func (tv *TV) validate(msg *Message, res Message) error {
+	err := res.Validate()
+	if apiErr, ok := err.(*APIError); ok {
+		apiErr.URI = msg.URI
 	}
+	return permissionError(msg.URI, err)
 }
 
 // setupResponseChannel ensures a channel is available for the given Message ID responses.
-func (tv *TV) setupResponseChannel(id string) chan Message {
+// Pooled channels are reused once torn down, so they must not be read afterwards. An
+// ID which is already awaiting responses is rejected, rather than taking its
+// responses.
+func (tv *TV) setupResponseChannel(id string, pooled bool) (chan Message, error) {
 	tv.resMutex.Lock()
 	defer tv.resMutex.Unlock()
 
+	if tv.closed {
+		return nil, fmt.Errorf("no response: %w", ErrConnectionClosed)
+	}
+	if _, ok := tv.res[id]; ok {
+		return nil, errors.Errorf("duplicate message ID: %s", id)
+	}
+
+	var ch chan Message
+	if pooled {
+		ch = responseChannels.Get().(chan Message)
+	} else {
+		ch = make(chan Message, responseBuffer)
+	}
+
+	p := &pendingResponse{ch: ch, pooled: pooled}
+	if tv.logger != nil {
+		p.sent = time.Now()
+	}
+
 	if tv.res == nil {
-		tv.res = make(map[string]chan<- Message)
+		tv.res = make(map[string]*pendingResponse)
 	}
+	tv.res[id] = p
 
-	ch := make(chan Message, 1)
-	tv.res[id] = ch
-	return ch
+	return ch, nil
 }
 
 // teardownResponseChannel removes the channels used by the given Message ID.
 func (tv *TV) teardownResponseChannel(id string) {
 	tv.resMutex.Lock()
-	defer tv.resMutex.Unlock()
+	p, ok := tv.res[id]
+	delete(tv.res, id)
+	tv.resMutex.Unlock()
 
-	if ch, ok := tv.res[id]; ok {
-		close(ch)
-		delete(tv.res, id)
+	// the channel was closed by the MessageHandler if it isn't found
+	if ok {
+		p.release()
 	}
 }
 
-// requestID returns a random 8 character string. Requests and Responses sent to and from
-// the TV are linked by this ID.
-func requestID() string {
-	rs := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-	b := make([]rune, 8)
-	for i := range b {
-		b[i] = rs[rand.Intn(len(rs))]
+// Input returns the pointer input socket, connecting to it if needed. It's safe to
+// call concurrently, only one socket is connected.
+func (tv *TV) Input() (*Input, error) {
+	tv.inputMutex.Lock()
+	defer tv.inputMutex.Unlock()
+
+	if tv.input == nil {
+		input, err := tv.createInput()
+		if err != nil {
+			return nil, err
+		}
+		tv.input = input
 	}
-	return string(b)
+	return tv.input, nil
 }
 
 // createInput create if needed an input
 func (tv *TV) createInput() (*Input, error) {
+	if err := tv.Capabilities().check(GetPointerInputSocketCommand); err != nil {
+		return nil, err
+	}
+	if err := tv.checkPermission(GetPointerInputSocketCommand); err != nil {
+		return nil, err
+	}
+
 	msg := Message{
 		Type: RequestMessageType,
-		ID:   requestID(),
+		ID:   tv.ids.next(),
 		URI:  GetPointerInputSocketCommand,
 	}
 	res, err := tv.request(&msg)
 	if err != nil {
-		return nil, fmt.Errorf("could not make request: %v", err)
+		return nil, fmt.Errorf("could not make request: %w", err)
 	}
 	var socketPath string
 	socketPath = fmt.Sprintf("%s", res.Payload["socketPath"])
 
-	input, err := NewInput(socketPath)
+	input, err := newInput(socketPath, tv.inputTLS)
 	if err != nil {
-		return nil, fmt.Errorf("could not dial: %v", err)
+		return nil, fmt.Errorf("could not dial: %w", err)
+	}
+
+	if tv.logger != nil {
+		input.SetLogger(tv.logger.Handler())
 	}
+	input.log(slog.LevelInfo, "connected")
 	return input, nil
 }
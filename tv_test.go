@@ -0,0 +1,373 @@
+package webos_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// dial dials the Server without registering.
+func dial(t testing.TB, srv *webostest.Server) *webos.TV {
+	t.Helper()
+
+	tv, err := webos.Dial(srv.Host(), webos.WithDialer(srv.Dialer()))
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	t.Cleanup(func() { tv.Close() })
+	go tv.MessageHandler()
+	return tv
+}
+
+// eventually calls f until it returns true, failing the test if it doesn't within a second.
+func eventually(t *testing.T, f func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); !f(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+	}
+}
+
+func TestAuthorisePrompt(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	key, err := dial(t, srv).AuthorisePrompt()
+	if err != nil {
+		t.Fatalf("AuthorisePrompt: %v", err)
+	}
+	if key != webostest.DefaultClientKey {
+		t.Errorf("client key is %q, want %q", key, webostest.DefaultClientKey)
+	}
+}
+
+func TestAuthorisePromptDenied(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.DenyPairing = true
+
+	if _, err := dial(t, srv).AuthorisePrompt(); err == nil {
+		t.Fatal("AuthorisePrompt succeeded after the prompt was denied")
+	}
+}
+
+func TestAuthorisePIN(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	key, err := dial(t, srv).AuthorisePIN(func() (string, error) {
+		return webostest.DefaultPIN, nil
+	})
+	if err != nil {
+		t.Fatalf("AuthorisePIN: %v", err)
+	}
+	if key != webostest.DefaultClientKey {
+		t.Errorf("client key is %q, want %q", key, webostest.DefaultClientKey)
+	}
+}
+
+func TestAuthorisePINInvalid(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	_, err := dial(t, srv).AuthorisePIN(func() (string, error) {
+		return "00000000", nil
+	})
+	var apiErr *webos.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("AuthorisePIN returned %v, want an *APIError", err)
+	}
+}
+
+func TestAuthoriseClientKey(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"volume": 10})
+
+	tv := dial(t, srv)
+	if _, err := tv.Command(webos.AudioGetVolumeCommand, nil); !errors.Is(err, webos.ErrNotRegistered) {
+		t.Errorf("Command before authorising returned %v, want ErrNotRegistered", err)
+	}
+
+	if err := tv.AuthoriseClientKey(webostest.DefaultClientKey); err != nil {
+		t.Fatalf("AuthoriseClientKey: %v", err)
+	}
+
+	msg, err := tv.Command(webos.AudioGetVolumeCommand, nil)
+	if err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if v := msg.Payload["volume"]; v != float64(10) {
+		t.Errorf("volume is %v, want 10", v)
+	}
+}
+
+func TestAuthoriseClientKeyPermissions(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv := dial(t, srv)
+	if err := tv.AuthoriseClientKey(webostest.DefaultClientKey); err != nil {
+		t.Fatalf("AuthoriseClientKey: %v", err)
+	}
+
+	// the key is assumed to be paired with the DefaultManifestPreset
+	granted := make(map[string]bool)
+	for _, p := range tv.Permissions() {
+		granted[p] = true
+	}
+	for _, p := range webos.ManifestPresets[webos.DefaultManifestPreset] {
+		if !granted[p] {
+			t.Errorf("%s wasn't granted", p)
+		}
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	// the Server doesn't respond until the test ends
+	block := make(chan struct{})
+	defer close(block)
+	srv.Handle(webos.SystemTurnOffCommand, func(webos.Message) webos.Message {
+		<-block
+		return webostest.Response(nil)
+	})
+
+	tv := connect(t, srv)
+	tv.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := tv.Command(webos.SystemTurnOffCommand, nil); !errors.Is(err, webos.ErrTimeout) {
+		t.Fatalf("Command returned %v, want ErrTimeout", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Command returned after %s, want 50ms", d)
+	}
+}
+
+func TestCommandContext(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+	srv.Handle(webos.SystemTurnOffCommand, func(webos.Message) webos.Message {
+		<-block
+		return webostest.Response(nil)
+	})
+
+	tv := connect(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := tv.CommandContext(ctx, webos.SystemTurnOffCommand, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CommandContext returned %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCommandConnectionClosed(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+	srv.Handle(webos.SystemTurnOffCommand, func(webos.Message) webos.Message {
+		<-block
+		return webostest.Response(nil)
+	})
+
+	tv := connect(t, srv)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		tv.Close()
+	}()
+
+	if _, err := tv.Command(webos.SystemTurnOffCommand, nil); !errors.Is(err, webos.ErrConnectionClosed) {
+		t.Fatalf("Command returned %v, want ErrConnectionClosed", err)
+	}
+}
+
+func BenchmarkCommand(b *testing.B) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 10, "muted": false})
+
+	tv := connect(b, srv)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tv.Command(webos.AudioGetVolumeCommand, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCommandParallel(b *testing.B) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 10, "muted": false})
+
+	tv := connect(b, srv)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := tv.Command(webos.AudioGetVolumeCommand, nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 10, "muted": false})
+
+	tv := connect(t, srv)
+	sub, err := tv.Subscribe(webos.AudioGetVolumeCommand, nil)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	var v webos.Volume
+	if err := sub.Decode(<-sub.C, &v); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if v.Volume != 10 {
+		t.Errorf("initial volume is %d, want 10", v.Volume)
+	}
+
+	srv.Publish(webos.AudioGetVolumeCommand, webos.Payload{"scenario": "mastervolume_tv_speaker", "volume": 11, "muted": true})
+	select {
+	case msg := <-sub.C:
+		if err := sub.Decode(msg, &v); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if v.Volume != 11 || !v.Muted {
+			t.Errorf("published volume is %+v, want 11 and muted", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no response after publishing")
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range sub.C {
+	}
+
+	eventually(t, func() bool {
+		msgs := srv.Messages()
+		last := msgs[len(msgs)-1]
+		return last.Type == webos.UnsubscribeMessageType && last.ID == sub.ID
+	})
+}
+
+func TestSubscribeError(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondError(webos.AudioGetVolumeCommand, 401, "insufficient permissions")
+
+	if _, err := connect(t, srv).Subscribe(webos.AudioGetVolumeCommand, nil); !errors.Is(err, webos.ErrPermissionDenied) {
+		t.Fatalf("Subscribe returned %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestInputButtons(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv := connect(t, srv)
+	for _, press := range []func() error{tv.KeyUp, tv.KeyDown, tv.KeyLeft, tv.KeyRight, tv.KeyBack, tv.KeyHome} {
+		if err := press(); err != nil {
+			t.Fatalf("could not press key: %v", err)
+		}
+	}
+
+	want := []string{"UP", "DOWN", "LEFT", "RIGHT", "BACK", "HOME"}
+	eventually(t, func() bool { return reflect.DeepEqual(srv.Buttons(), want) })
+}
+
+func TestPressButton(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondStubs()
+
+	tv := connect(t, srv)
+	for _, name := range webos.Buttons {
+		if err := tv.PressButton(name); err != nil {
+			t.Fatalf("PressButton(%q): %v", name, err)
+		}
+	}
+	if err := tv.PressButton(" Home "); err != nil {
+		t.Fatalf("PressButton is case sensitive: %v", err)
+	}
+	if err := tv.PressButton("eject"); !errors.Is(err, webos.ErrUnknownButton) {
+		t.Errorf("PressButton returned %v for an unknown button, want ErrUnknownButton", err)
+	}
+
+	want := []string{"UP", "DOWN", "LEFT", "RIGHT", "BACK", "HOME", "HOME"}
+	eventually(t, func() bool { return reflect.DeepEqual(srv.Buttons(), want) })
+}
+
+func TestInputMoves(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	input, err := connect(t, srv).Input()
+	if err != nil {
+		t.Fatalf("Input: %v", err)
+	}
+
+	if err := input.Move(10, -5); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if err := input.Move(-3, 2); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	want := [][2]int{{10, -5}, {-3, 2}}
+	eventually(t, func() bool { return reflect.DeepEqual(srv.Moves(), want) })
+}
+
+func TestInputConcurrent(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv := connect(t, srv)
+
+	var wg sync.WaitGroup
+	inputs := make([]*webos.Input, 8)
+	for i := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input, err := tv.Input()
+			if err != nil {
+				t.Error(err)
+			}
+			inputs[i] = input
+		}()
+	}
+	wg.Wait()
+
+	for _, input := range inputs {
+		if input != inputs[0] {
+			t.Fatal("concurrent calls to Input connected several sockets")
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package webosotel traces the requests made to TVs, and pairing, with OpenTelemetry.
+// Spans are children of the span in the context passed to CommandContext, so slow TV
+// responses show up in the caller's traces.
+//
+//	tv.Use(webosotel.Middleware())
+//
+//	if err := webosotel.AuthoriseClientKey(ctx, tv, key); err != nil {
+//		...
+//	}
+//	tv.CommandContext(ctx, webos.AudioGetVolumeCommand, nil)
+package webosotel
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+// instrumentationName is the name of the Tracer.
+const instrumentationName = "github.com/kaperys/go-webos/webosotel"
+
+// Attribute keys of the spans.
+const (
+	URIKey           = attribute.Key("webos.uri")
+	MessageIDKey     = attribute.Key("webos.message.id")
+	MessageTypeKey   = attribute.Key("webos.message.type")
+	ResponseTypeKey  = attribute.Key("webos.response.type")
+	ErrorCodeKey     = attribute.Key("webos.error.code")
+	PairingMethodKey = attribute.Key("webos.pairing.method")
+)
+
+// config is the configuration set by Options.
+type config struct {
+	provider trace.TracerProvider
+}
+
+// Option configures the tracing.
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider used to create spans. The global
+// TracerProvider is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = tp
+	}
+}
+
+// tracer returns the Tracer configured by the Options.
+func tracer(opts []Option) trace.Tracer {
+	c := config{provider: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c.provider.Tracer(instrumentationName)
+}
+
+// Middleware returns Middleware which starts a client span for each request, named by
+// the Command URI, e.g. `ssap://audio/getVolume`, or `register` for registration.
+func Middleware(opts ...Option) webos.Middleware {
+	t := tracer(opts)
+
+	return func(next webos.Handler) webos.Handler {
+		return func(ctx context.Context, msg *webos.Message) (webos.Message, error) {
+			name := string(msg.URI)
+			if name == "" {
+				name = string(msg.Type)
+			}
+
+			ctx, span := t.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					URIKey.String(string(msg.URI)),
+					MessageIDKey.String(msg.ID),
+					MessageTypeKey.String(string(msg.Type)),
+				),
+			)
+			defer span.End()
+
+			res, err := next(ctx, msg)
+
+			if res.Type != "" {
+				span.SetAttributes(ResponseTypeKey.String(string(res.Type)))
+			}
+
+			var apiErr *webos.APIError
+			if errors.As(err, &apiErr) {
+				span.SetAttributes(ErrorCodeKey.Int(apiErr.Code))
+			}
+
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return res, err
+		}
+	}
+}
+
+// AuthoriseClientKey authorises with the TV using an existing client key in a `pair`
+// span. The requests made are traced if the TV uses Middleware.
+func AuthoriseClientKey(ctx context.Context, tv *webos.TV, key string, opts ...Option) error {
+	return pair(ctx, "client-key", opts, func(ctx context.Context) error {
+		return tv.AuthoriseClientKeyContext(ctx, key)
+	})
+}
+
+// AuthorisePrompt authorises with the TV using the PROMPT method in a `pair` span.
+// The span includes the time taken to accept the prompt.
+func AuthorisePrompt(ctx context.Context, tv *webos.TV, opts ...Option) (string, error) {
+	var key string
+	err := pair(ctx, "prompt", opts, func(ctx context.Context) (err error) {
+		key, err = tv.AuthorisePromptContext(ctx)
+		return err
+	})
+	return key, err
+}
+
+// AuthorisePIN authorises with the TV using the PIN method in a `pair` span. The span
+// includes the time taken to enter the PIN.
+func AuthorisePIN(ctx context.Context, tv *webos.TV, pin func() (string, error), opts ...Option) (string, error) {
+	var key string
+	err := pair(ctx, "pin", opts, func(ctx context.Context) (err error) {
+		key, err = tv.AuthorisePINContext(ctx, pin)
+		return err
+	})
+	return key, err
+}
+
+// pair calls the pairing function in a `pair` span.
+func pair(ctx context.Context, method string, opts []Option, fn func(ctx context.Context) error) error {
+	ctx, span := tracer(opts).Start(ctx, "pair",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(PairingMethodKey.String(method)),
+	)
+	defer span.End()
+
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package webosotel_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	webos "github.com/kaperys/go-webos"
+	"github.com/kaperys/go-webos/webosotel"
+	"github.com/kaperys/go-webos/webostest"
+)
+
+// traced dials the Server with the Middleware recording spans to the SpanRecorder.
+func traced(t *testing.T, srv *webostest.Server) (*webos.TV, *sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	tv, err := webos.Dial(srv.Host(), webos.WithDialer(srv.Dialer()))
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	t.Cleanup(func() { tv.Close() })
+	go tv.MessageHandler()
+
+	tv.Use(webosotel.Middleware(webosotel.WithTracerProvider(tp)))
+	return tv, tp, sr
+}
+
+// span returns the ended span with the name, failing the test if there isn't one.
+func span(t *testing.T, sr *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	for _, s := range sr.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("no %s span", name)
+	return nil
+}
+
+// attr returns the value of the span's attribute.
+func attr(s sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestPairSpan(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+
+	tv, tp, sr := traced(t, srv)
+	err := webosotel.AuthoriseClientKey(context.Background(), tv, webostest.DefaultClientKey, webosotel.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("AuthoriseClientKey: %v", err)
+	}
+
+	pair := span(t, sr, "pair")
+	if v := attr(pair, webosotel.PairingMethodKey).AsString(); v != "client-key" {
+		t.Errorf("pairing method is %q, want client-key", v)
+	}
+	if pair.Status().Code == codes.Error {
+		t.Errorf("pair span has an error status: %s", pair.Status().Description)
+	}
+
+	register := span(t, sr, "register")
+	if register.Parent().SpanID() != pair.SpanContext().SpanID() {
+		t.Error("the register span isn't a child of the pair span")
+	}
+	if v := attr(register, webosotel.MessageTypeKey).AsString(); v != string(webos.RegisterMessageType) {
+		t.Errorf("message type is %q, want register", v)
+	}
+	if v := attr(register, webosotel.ResponseTypeKey).AsString(); v != string(webos.RegisteredMessageType) {
+		t.Errorf("response type is %q, want registered", v)
+	}
+}
+
+func TestRequestSpan(t *testing.T) {
+	srv := webostest.NewServer()
+	defer srv.Close()
+	srv.RespondError(webos.AudioGetVolumeCommand, 401, "insufficient permissions")
+
+	tv, tp, sr := traced(t, srv)
+	if err := tv.AuthoriseClientKey(webostest.DefaultClientKey); err != nil {
+		t.Fatalf("AuthoriseClientKey: %v", err)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "caller")
+	_, err := tv.CommandContext(ctx, webos.AudioGetVolumeCommand, nil)
+	parent.End()
+	if err == nil {
+		t.Fatal("CommandContext succeeded, want the TV's error")
+	}
+
+	s := span(t, sr, string(webos.AudioGetVolumeCommand))
+	if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("the request span isn't a child of the caller's span")
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("status is %v, want Error", s.Status().Code)
+	}
+	if len(s.Events()) == 0 || s.Events()[0].Name != "exception" {
+		t.Error("the error wasn't recorded")
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		webosotel.URIKey:          attribute.StringValue(string(webos.AudioGetVolumeCommand)),
+		webosotel.MessageTypeKey:  attribute.StringValue(string(webos.RequestMessageType)),
+		webosotel.ResponseTypeKey: attribute.StringValue(string(webos.ErrorMessageType)),
+		webosotel.ErrorCodeKey:    attribute.IntValue(401),
+	}
+	for key, v := range want {
+		if got := attr(s, key); got != v {
+			t.Errorf("%s is %v, want %v", key, got.Emit(), v.Emit())
+		}
+	}
+	if attr(s, webosotel.MessageIDKey).AsString() == "" {
+		t.Error("no message ID attribute")
+	}
+}
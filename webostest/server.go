@@ -0,0 +1,453 @@
+// Package webostest provides a fake webOS TV for testing code which uses the webos package.
+//
+// The Server speaks SSAP over a TLS websocket. It handles registration using the
+// PROMPT, PIN and client key methods, answers requests with scripted responses,
+// serves a pointer input socket and records every Message it receives.
+//
+//	srv := webostest.NewServer()
+//	defer srv.Close()
+//
+//	srv.Respond(webos.AudioGetVolumeCommand, webos.Payload{"volume": 10, "muted": false, "scenario": "mastervolume_tv_speaker"})
+//
+//	tv, err := webos.Dial(srv.Host(), webos.WithDialer(srv.Dialer()))
+package webostest
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	webos "github.com/kaperys/go-webos"
+)
+
+const (
+	// DefaultClientKey is the client key returned by pairing and accepted by client key registration.
+	DefaultClientKey = "0123456789abcdef0123456789abcdef"
+
+	// DefaultPIN is the PIN expected by PIN pairing.
+	DefaultPIN = "12345678"
+)
+
+// HandlerFunc returns the response to a request or subscription. The ID of the returned
+// Message is set by the Server.
+type HandlerFunc func(req webos.Message) webos.Message
+
+// Server is a fake webOS TV.
+type Server struct {
+	// ClientKey is returned by pairing and accepted by client key registration.
+	ClientKey string
+
+	// PIN is expected by PIN pairing.
+	PIN string
+
+	// AcceptAnyClientKey accepts client key registration with any key.
+	AcceptAnyClientKey bool
+
+	// DenyPairing rejects PROMPT pairing, as if the user declined the prompt on the TV.
+	DenyPairing bool
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	handlers map[webos.Command]HandlerFunc
+	received []webos.Message
+	buttons  []string
+	moves    [][2]int
+	conns    map[*conn]bool
+}
+
+// conn is a connection to the Server's SSAP socket.
+type conn struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+
+	registered bool
+	pinID      string
+	subs       map[string]webos.Command
+}
+
+// write writes msg to the connection.
+func (c *conn) write(msg webos.Message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, b)
+}
+
+// NewServer starts and returns a new Server. The Server should be closed when finished.
+func NewServer() *Server {
+	s := &Server{
+		ClientKey: DefaultClientKey,
+		PIN:       DefaultPIN,
+		handlers:  make(map[webos.Command]HandlerFunc),
+		conns:     make(map[*conn]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveSSAP)
+	mux.HandleFunc("/pointer", s.servePointer)
+
+	s.srv = httptest.NewTLSServer(mux)
+
+	s.Handle(webos.GetPointerInputSocketCommand, func(webos.Message) webos.Message {
+		return Response(webos.Payload{"socketPath": "wss://" + s.srv.Listener.Addr().String() + "/pointer"})
+	})
+
+	return s
+}
+
+// Close closes all connections and shuts down the Server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	for c := range s.conns {
+		c.ws.Close()
+	}
+	s.mu.Unlock()
+
+	s.srv.Close()
+}
+
+// Host returns the host which should be passed to webos.Dial along with the Dialer.
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.srv.Listener.Addr().String())
+	return host
+}
+
+// Dialer returns a websocket.Dialer which connects to the Server regardless of the
+// address dialed, so the TV's default protocol and port can be used.
+func (s *Server) Dialer() *websocket.Dialer {
+	addr := s.srv.Listener.Addr().String()
+	return &websocket.Dialer{
+		HandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
+		NetDial: func(network, _ string) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+}
+
+// Handle registers the HandlerFunc used to respond to requests and subscriptions for uri.
+func (s *Server) Handle(uri webos.Command, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[uri] = h
+}
+
+// Respond responds to requests and subscriptions for uri with the Payload. `returnValue`
+// is set to true if it isn't present in the Payload.
+func (s *Server) Respond(uri webos.Command, p webos.Payload) {
+	s.Handle(uri, func(webos.Message) webos.Message {
+		return Response(p)
+	})
+}
+
+// RespondStubs responds to requests for every Command of the SSAP spec with the
+// example response in the spec, or an empty response, e.g. so the getters of a TV return
+// realistic values. Commands already handled, e.g. by Respond, keep their responses.
+func (s *Server) RespondStubs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uri, p := range stubs {
+		if _, ok := s.handlers[uri]; ok {
+			continue
+		}
+		s.handlers[uri] = func(webos.Message) webos.Message {
+			return Response(p)
+		}
+	}
+}
+
+// RespondError responds to requests for uri with an error Message, e.g. code 401 and
+// text "insufficient permissions".
+func (s *Server) RespondError(uri webos.Command, code int, text string) {
+	s.Handle(uri, func(webos.Message) webos.Message {
+		return Error(code, text)
+	})
+}
+
+// Publish sends the Payload to every subscription to uri, as the TV does when the
+// subscribed state changes.
+func (s *Server) Publish(uri webos.Command, p webos.Payload) {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.mu.Lock()
+		var ids []string
+		for id, sub := range c.subs {
+			if sub == uri {
+				ids = append(ids, id)
+			}
+		}
+		c.mu.Unlock()
+
+		for _, id := range ids {
+			res := Response(p)
+			res.ID = id
+			c.write(res)
+		}
+	}
+}
+
+// Messages returns every Message received by the Server on the SSAP socket.
+func (s *Server) Messages() []webos.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webos.Message(nil), s.received...)
+}
+
+// Buttons returns the names of every button received on the pointer input socket.
+func (s *Server) Buttons() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.buttons...)
+}
+
+// Moves returns the dx and dy of every pointer move received on the pointer input socket.
+func (s *Server) Moves() [][2]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][2]int(nil), s.moves...)
+}
+
+// Response returns a response Message with the Payload. `returnValue` is set to true
+// if it isn't present in the Payload.
+func Response(p webos.Payload) webos.Message {
+	res := webos.Payload{"returnValue": true}
+	for k, v := range p {
+		res[k] = v
+	}
+	return webos.Message{Type: webos.ResponseMessageType, Payload: res}
+}
+
+// Error returns an error Message, e.g. code 404 and text "no such service or method".
+func Error(code int, text string) webos.Message {
+	return webos.Message{
+		Type:    webos.ErrorMessageType,
+		Error:   strings.TrimSpace(strconv.Itoa(code) + " " + text),
+		Payload: webos.Payload{},
+	}
+}
+
+// serveSSAP serves the SSAP socket.
+func (s *Server) serveSSAP(w http.ResponseWriter, r *http.Request) {
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{ws: ws, subs: make(map[string]webos.Command)}
+
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		_, p, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		msg := webos.Message{}
+		if err := json.Unmarshal(p, &msg); err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.received = append(s.received, msg)
+		s.mu.Unlock()
+
+		for _, res := range s.handle(c, msg) {
+			if err := c.write(res); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle returns the responses to msg.
+func (s *Server) handle(c *conn, msg webos.Message) []webos.Message {
+	switch msg.Type {
+	case webos.RegisterMessageType:
+		return s.register(c, msg)
+	case webos.UnsubscribeMessageType:
+		c.mu.Lock()
+		delete(c.subs, msg.ID)
+		c.mu.Unlock()
+		return nil
+	case webos.RequestMessageType, webos.SubscribeMessageType:
+	default:
+		return []webos.Message{withID(Error(400, "unknown message type"), msg.ID)}
+	}
+
+	c.mu.Lock()
+	registered := c.registered
+	c.mu.Unlock()
+
+	if msg.URI == webos.PairingSetPINCommand {
+		return s.setPIN(c, msg)
+	}
+
+	if !registered {
+		return []webos.Message{withID(Error(401, "insufficient permissions (not registered)"), msg.ID)}
+	}
+
+	s.mu.Lock()
+	h, ok := s.handlers[msg.URI]
+	s.mu.Unlock()
+
+	if !ok {
+		return []webos.Message{withID(Error(404, "no such service or method"), msg.ID)}
+	}
+
+	res := h(msg)
+	if msg.Type == webos.SubscribeMessageType && res.Type == webos.ResponseMessageType {
+		c.mu.Lock()
+		c.subs[msg.ID] = msg.URI
+		c.mu.Unlock()
+
+		if res.Payload != nil {
+			res.Payload["subscribed"] = true
+		}
+	}
+
+	return []webos.Message{withID(res, msg.ID)}
+}
+
+// register handles the registration flows.
+func (s *Server) register(c *conn, msg webos.Message) []webos.Message {
+	if key, ok := msg.Payload["client-key"].(string); ok && (key == s.ClientKey || s.AcceptAnyClientKey) {
+		return []webos.Message{s.registered(c, msg.ID)}
+	}
+
+	switch msg.Payload["pairingType"] {
+	case "PIN":
+		c.mu.Lock()
+		c.pinID = msg.ID
+		c.mu.Unlock()
+
+		return []webos.Message{withID(Response(webos.Payload{"pairingType": "PIN"}), msg.ID)}
+	default:
+		if s.DenyPairing {
+			return []webos.Message{withID(Error(403, "User denied access"), msg.ID)}
+		}
+
+		return []webos.Message{
+			withID(Response(webos.Payload{"pairingType": "PROMPT"}), msg.ID),
+			s.registered(c, msg.ID),
+		}
+	}
+}
+
+// setPIN handles the PIN sent during PIN pairing.
+func (s *Server) setPIN(c *conn, msg webos.Message) []webos.Message {
+	c.mu.Lock()
+	id := c.pinID
+	c.mu.Unlock()
+
+	if id == "" {
+		return []webos.Message{withID(Error(400, "no pairing in progress"), msg.ID)}
+	}
+
+	if pin, _ := msg.Payload["pin"].(string); pin != s.PIN {
+		return []webos.Message{withID(Response(webos.Payload{
+			"returnValue": false,
+			"errorCode":   -1,
+			"errorText":   "Invalid PIN",
+		}), msg.ID)}
+	}
+
+	c.mu.Lock()
+	c.pinID = ""
+	c.mu.Unlock()
+
+	return []webos.Message{
+		withID(Response(nil), msg.ID),
+		s.registered(c, id),
+	}
+}
+
+// registered marks the connection registered and returns the registered Message.
+func (s *Server) registered(c *conn, id string) webos.Message {
+	c.mu.Lock()
+	c.registered = true
+	c.mu.Unlock()
+
+	return webos.Message{
+		Type:    webos.RegisteredMessageType,
+		ID:      id,
+		Payload: webos.Payload{"client-key": s.ClientKey},
+	}
+}
+
+// servePointer serves the pointer input socket.
+func (s *Server) servePointer(w http.ResponseWriter, r *http.Request) {
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	for {
+		_, p, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		fields := parsePointerMessage(string(p))
+		switch fields["type"] {
+		case "button":
+			s.mu.Lock()
+			s.buttons = append(s.buttons, fields["name"])
+			s.mu.Unlock()
+		case "move":
+			dx, _ := strconv.Atoi(fields["dx"])
+			dy, _ := strconv.Atoi(fields["dy"])
+			s.mu.Lock()
+			s.moves = append(s.moves, [2]int{dx, dy})
+			s.mu.Unlock()
+		}
+	}
+}
+
+// parsePointerMessage parses a pointer input socket message in the format
+// "type:button\nname:UP\n\n".
+func parsePointerMessage(m string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(m, "\n") {
+		if i := strings.Index(line, ":"); i > 0 {
+			fields[line[:i]] = line[i+1:]
+		}
+	}
+	return fields
+}
+
+// withID returns msg with the ID set.
+func withID(msg webos.Message, id string) webos.Message {
+	msg.ID = id
+	return msg
+}
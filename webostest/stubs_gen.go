@@ -0,0 +1,200 @@
+// Code generated by ssapgen from spec/ssap.yaml. DO NOT EDIT.
+
+package webostest
+
+import webos "github.com/kaperys/go-webos"
+
+// stubs are the example responses of the Commands, served by RespondStubs.
+var stubs = map[webos.Command]webos.Payload{
+	webos.APIServiceListCommand: {
+		"services": []interface{}{
+			map[string]interface{}{"name": "api", "version": 1},
+			map[string]interface{}{"name": "audio", "version": 1},
+			map[string]interface{}{"name": "media.controls", "version": 1},
+			map[string]interface{}{"name": "pairing", "version": 1},
+			map[string]interface{}{"name": "settings", "version": 1},
+			map[string]interface{}{"name": "system", "version": 1},
+			map[string]interface{}{"name": "system.launcher", "version": 1},
+			map[string]interface{}{"name": "system.notifications", "version": 1},
+			map[string]interface{}{"name": "tv", "version": 1},
+		},
+	},
+	webos.ApplicationManagerForegroundAppCommand: {
+		"appId":     "com.webos.app.livetv",
+		"processId": "",
+		"windowId":  "",
+	},
+	webos.ApplicationManagerListAppsCommand: {
+		"apps": []interface{}{
+			map[string]interface{}{
+				"id":      "netflix",
+				"title":   "Netflix",
+				"version": "1.0",
+				"visible": true,
+			},
+			map[string]interface{}{
+				"id":      "youtube.leanback.v4",
+				"title":   "YouTube",
+				"version": "1.0",
+				"visible": true,
+			},
+		},
+	},
+	webos.ApplicationManagerListLaunchPointsCommand: {
+		"launchPoints": []interface{}{
+			map[string]interface{}{
+				"id":            "netflix",
+				"launchPointId": "netflix_default",
+				"removable":     true,
+				"title":         "Netflix",
+			},
+			map[string]interface{}{
+				"id":            "com.webos.app.hdmi1",
+				"launchPointId": "com.webos.app.hdmi1_default",
+				"removable":     false,
+				"title":         "HDMI 1",
+			},
+		},
+	},
+	webos.AudioGetVolumeCommand: {
+		"volumeStatus": map[string]interface{}{
+			"maxVolume":   100,
+			"muteStatus":  false,
+			"soundOutput": "tv_speaker",
+			"volume":      10,
+		},
+	},
+	webos.AudioSetVolumeCommand:     {},
+	webos.AudioVolumeDownCommand:    {},
+	webos.AudioVolumeUpCommand:      {},
+	webos.AudioVolumeSetMuteCommand: {},
+	webos.AudioGetStatusCommand: {
+		"mute":     false,
+		"scenario": "mastervolume_tv_speaker",
+		"volume":   10,
+	},
+	webos.AudioGetSoundOutputCommand:     {"soundOutput": "tv_speaker"},
+	webos.AudioChangeSoundOutputCommand:  {},
+	webos.MediaForegroundAppCommand:      {},
+	webos.MediaControlFastForwardCommand: {},
+	webos.MediaControlPauseCommand:       {},
+	webos.MediaControlPlayCommand:        {},
+	webos.MediaControlRewindCommand:      {},
+	webos.MediaControlStopCommand:        {},
+	webos.MediaViewerOpenCommand: {
+		"id":        "com.webos.app.mediadiscovery",
+		"sessionId": "c2Vzc2lvbg==",
+	},
+	webos.MediaViewerCloseCommand:          {},
+	webos.PairingSetPINCommand:             {},
+	webos.SystemLauncherCloseCommand:       {},
+	webos.SystemLauncherGetAppStateCommand: {"running": true, "visible": true},
+	webos.SystemLauncherLaunchCommand:      {},
+	webos.SystemLauncherOpenCommand:        {},
+	webos.WebAppLaunchCommand: {
+		"sessionId": "c2Vzc2lvbg==",
+		"webAppId":  "com.example.webapp",
+	},
+	webos.WebAppCloseCommand:                    {},
+	webos.WebAppPinCommand:                      {},
+	webos.WebAppIsPinnedCommand:                 {"pinned": false},
+	webos.SystemNotificationsCreateToastCommand: {},
+	webos.SystemNotificationsCreateAlertCommand: {"alertId": "com.webos.service.apiadapter-1"},
+	webos.SystemNotificationsCloseAlertCommand:  {},
+	webos.SettingsGetSystemSettingsCommand: {
+		"category": "picture",
+		"settings": map[string]interface{}{
+			"backlight":      "80",
+			"brightness":     "50",
+			"color":          "50",
+			"contrast":       "85",
+			"energySaving":   "auto",
+			"eyeComfortMode": "off",
+			"pictureMode":    "eco",
+		},
+	},
+	webos.SystemGetSystemInfoCommand: {
+		"features":     map[string]interface{}{"3d": false, "dvr": true},
+		"modelName":    "43UH668V",
+		"receiverType": "dvb",
+	},
+	webos.SystemTurnOffCommand: {},
+	webos.PowerStateCommand:    {"state": "Active"},
+	webos.ScreenOffCommand:     {},
+	webos.ScreenOnCommand:      {},
+	webos.SoftwareInfoCommand: {
+		"country":      "GB",
+		"major_ver":    "05",
+		"minor_ver":    "30.20",
+		"model_name":   "HE_DTV_W16P_AFADATAA",
+		"product_name": "webOSTV 3.0",
+	},
+	webos.TVDisplayGet3DStatusCommand: {
+		"status3D": map[string]interface{}{"pattern": "2D", "status": false},
+	},
+	webos.TVDisplaySet3DOnCommand:  {},
+	webos.TVDisplaySet3DOffCommand: {},
+	webos.TVChannelDownCommand:     {},
+	webos.TVChannelListCommand:     {},
+	webos.TVChannelUpCommand:       {},
+	webos.TVOpenChannelCommand:     {},
+	webos.TVExternalInputListCommand: {
+		"devices": []interface{}{
+			map[string]interface{}{
+				"appId":     "com.webos.app.hdmi1",
+				"connected": true,
+				"id":        "HDMI_1",
+				"label":     "HDMI 1",
+				"port":      1,
+			},
+			map[string]interface{}{
+				"appId":     "com.webos.app.hdmi2",
+				"connected": false,
+				"id":        "HDMI_2",
+				"label":     "HDMI 2",
+				"port":      2,
+			},
+		},
+	},
+	webos.TVSwitchInputCommand: {},
+	webos.TVCurrentChannelCommand: {
+		"channelId":     "1_11_1_0_0_0_0",
+		"channelName":   "BBC ONE",
+		"channelNumber": "1",
+	},
+	webos.TVCurrentChannelProgramCommand: {},
+	webos.TVChannelCurrentProgramCommand: {
+		"channelId":   "1_11_1_0_0_0_0",
+		"endTime":     "2024,01,02,04,00,00",
+		"programId":   "1",
+		"programName": "News",
+		"startTime":   "2024,01,02,03,00,00",
+	},
+	webos.TVGetCurrentTimeCommand: {
+		"time": map[string]interface{}{
+			"day":    2,
+			"hour":   3,
+			"minute": 4,
+			"month":  1,
+			"second": 5,
+			"year":   2024,
+		},
+	},
+	webos.GetPointerInputSocketCommand: {},
+	webos.ConnectionManagerGetInfoCommand: {
+		"isInternetConnectionAvailable": true,
+		"wifi":                          map[string]interface{}{"state": "disconnected"},
+		"wired": map[string]interface{}{
+			"interfaceName": "eth0",
+			"ipAddress":     "192.168.1.10",
+			"method":        "dhcp",
+			"state":         "connected",
+		},
+	},
+	webos.IMERegisterRemoteKeyboardCommand: {
+		"currentWidget": map[string]interface{}{"contentType": "text", "focus": false, "hiddenText": false},
+	},
+	webos.KeyEnterCommand:            {},
+	webos.IMEInsertTextCommand:       {},
+	webos.IMEDeleteCharactersCommand: {},
+}
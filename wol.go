@@ -0,0 +1,42 @@
+package webos
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// wakeAddr is the address Wake-on-LAN magic packets are broadcast to.
+const wakeAddr = "255.255.255.255:9"
+
+// WakeOnLAN turns the TV on by broadcasting a Wake-on-LAN magic packet to the TV's
+// MAC address. The TV can't be reached over the websocket while it is off, so this
+// isn't a TV method.
+func WakeOnLAN(mac string) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return errors.Wrap(err, "invalid MAC address")
+	}
+
+	if len(hw) != 6 {
+		return errors.Errorf("invalid MAC address: %s", mac)
+	}
+
+	// the magic packet is 6 bytes of 0xFF followed by the MAC address repeated 16 times
+	packet := append(bytes.Repeat([]byte{0xFF}, 6), bytes.Repeat(hw, 16)...)
+
+	addr, err := net.ResolveUDPAddr("udp4", wakeAddr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(packet)
+	return err
+}